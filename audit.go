@@ -0,0 +1,259 @@
+package tama
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/upmaru/tama-go/internal/transport"
+)
+
+// AuditResult is the outcome of an audited call.
+type AuditResult string
+
+// Audit results.
+const (
+	// AuditSuccess means the API accepted the call.
+	AuditSuccess AuditResult = "success"
+	// AuditFailure means the API rejected the call or could not be reached.
+	AuditFailure AuditResult = "failure"
+	// AuditQueued means the API could not be reached and the call was stored
+	// in the outbox. Replaying it produces another record.
+	AuditQueued AuditResult = "queued"
+)
+
+// AuditRecord describes a create, update, replace or delete call. Secret
+// fields of the request body and prior state are redacted like debug output.
+type AuditRecord struct {
+	Time time.Time `json:"time"`
+	// Actor labels who made the call, from WithActor or AuditConfig.Actor.
+	Actor string `json:"actor,omitempty"`
+	// Operation names the service method that issued the call, e.g. "sensory.UpdateSource".
+	Operation string `json:"operation"`
+	Method    string `json:"method"`
+	Path      string `json:"path"`
+	// ResourceID is the ID of the resource the call modified. For creates it
+	// is taken from the response, so it is empty if the create failed.
+	ResourceID string `json:"resource_id,omitempty"`
+	// ParentID is the ID of the parent resource a create call adds to.
+	ParentID string          `json:"parent_id,omitempty"`
+	Request  json.RawMessage `json:"request,omitempty"`
+	// Prior is the resource as returned by its Get endpoint just before an
+	// update, replace or delete, if AuditConfig.Prior is set.
+	Prior json.RawMessage `json:"prior,omitempty"`
+	// PriorError is set if the prior state could not be fetched.
+	PriorError string      `json:"prior_error,omitempty"`
+	Result     AuditResult `json:"result"`
+	// StatusCode is the status code of the response, or zero if none was received.
+	StatusCode int    `json:"status_code,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// AuditSink receives a record of every create, update, replace and delete
+// call a client makes. Record is called synchronously after the call
+// completes, possibly from several goroutines at once.
+type AuditSink interface {
+	Record(ctx context.Context, record AuditRecord) error
+}
+
+// AuditConfig enables the audit log.
+type AuditConfig struct {
+	// Sink receives the records. JSONLAuditSink writes them to a file.
+	Sink AuditSink
+	// Actor labels the calls of the client, e.g. a user or service name.
+	// WithActor overrides it per call.
+	Actor string
+	// Prior fetches the current state of the resource before every update,
+	// replace and delete, at the cost of an extra Get request per call. The
+	// Get always goes to the API, bypassing Config.Cache.
+	Prior bool
+}
+
+type actorKey struct{}
+
+// WithActor returns a copy of ctx that labels the calls made with it as made
+// by actor in the audit log, instead of AuditConfig.Actor.
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// JSONLAuditSink writes audit records as JSON Lines, one record per line.
+type JSONLAuditSink struct {
+	mu   sync.Mutex
+	w    io.Writer
+	file *os.File
+}
+
+// NewJSONLAuditSink returns a sink that writes records to w.
+func NewJSONLAuditSink(w io.Writer) *JSONLAuditSink {
+	return &JSONLAuditSink{w: w}
+}
+
+// OpenJSONLAuditSink returns a sink that appends records to the file at path,
+// creating it readable by its owner only if needed. Every record is synced to
+// disk before the call that produced it returns.
+func OpenJSONLAuditSink(path string) (*JSONLAuditSink, error) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	return &JSONLAuditSink{w: file, file: file}, nil
+}
+
+// Record implements AuditSink.
+func (s *JSONLAuditSink) Record(_ context.Context, record AuditRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit record: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.w.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write audit record: %w", err)
+	}
+	if s.file != nil {
+		if err := s.file.Sync(); err != nil {
+			return fmt.Errorf("failed to write audit record: %w", err)
+		}
+	}
+	return nil
+}
+
+// Close closes the file opened by OpenJSONLAuditSink. It does nothing for
+// sinks created with NewJSONLAuditSink.
+func (s *JSONLAuditSink) Close() error {
+	if s.file == nil {
+		return nil
+	}
+	return s.file.Close()
+}
+
+// audited reports whether req is a call the audit log records.
+func (c *Client) audited(req *http.Request) bool {
+	return c.config.Audit != nil && c.config.Audit.Sink != nil && req.Method != http.MethodGet &&
+		transport.FromContext(req.Context()) != nil
+}
+
+// priorState fetches the resource an update, replace or delete is about to
+// modify, if the audit log asks for it.
+func (c *Client) priorState(ctx context.Context, req *http.Request) (json.RawMessage, error) {
+	call := transport.FromContext(ctx)
+	if !c.audited(req) || !c.config.Audit.Prior || req.Method == http.MethodPost || call.ResourceID == "" {
+		return nil, nil
+	}
+
+	_, method, _ := strings.Cut(call.Operation, ".")
+	get := &transport.Call{
+		Operation:  call.Service() + ".Get" + method[len(call.Action()):],
+		Route:      call.Route,
+		ResourceID: call.ResourceID,
+	}
+	// A cached copy may predate changes made by other clients.
+	ctx = transport.NewContext(context.WithValue(ctx, cacheBypassKey{}, true), get)
+
+	url := strings.TrimSuffix(c.baseURL, "/") + get.Path()
+	getReq, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	getReq.Header.Set("Accept", "application/json")
+
+	resp, err := c.do(getReq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		return nil, &Error{StatusCode: resp.StatusCode}
+	}
+	return redactBody(body), nil
+}
+
+// audit records the outcome of a call made with req in the audit log. The
+// response body is read and restored, so the caller can still decode it.
+func (c *Client) audit(ctx context.Context, req *http.Request, prior json.RawMessage, priorErr error,
+	resp *http.Response, err error,
+) {
+	if !c.audited(req) {
+		return
+	}
+
+	call := transport.FromContext(ctx)
+	body, _ := readBody(req)
+	record := AuditRecord{
+		Time:       time.Now().UTC(),
+		Actor:      c.config.Audit.Actor,
+		Operation:  call.Operation,
+		Method:     req.Method,
+		Path:       req.URL.Path,
+		ResourceID: call.ResourceID,
+		ParentID:   call.ParentID,
+		Request:    redactBody(body),
+		Prior:      prior,
+		Result:     AuditSuccess,
+	}
+	if actor, ok := ctx.Value(actorKey{}).(string); ok {
+		record.Actor = actor
+	}
+	if priorErr != nil {
+		record.PriorError = priorErr.Error()
+	}
+
+	var queued *QueuedError
+	switch {
+	case errors.As(err, &queued):
+		record.Result, record.Error = AuditQueued, err.Error()
+	case err != nil:
+		record.Result, record.Error = AuditFailure, err.Error()
+	case resp.StatusCode >= http.StatusBadRequest:
+		record.Result, record.StatusCode = AuditFailure, resp.StatusCode
+		record.Error = (&Error{StatusCode: resp.StatusCode}).Error()
+	default:
+		record.StatusCode = resp.StatusCode
+		if record.ResourceID == "" {
+			record.ResourceID = createdID(resp)
+		}
+	}
+
+	if err := c.config.Audit.Sink.Record(ctx, record); err != nil {
+		if logger := c.enabledLogger(ctx, slog.LevelError); logger != nil {
+			logger.LogAttrs(ctx, slog.LevelError, "tama audit record lost",
+				slog.String("operation", record.Operation), slog.String("error", err.Error()))
+		}
+	}
+}
+
+// createdID returns the ID of the resource in a create response, restoring
+// the response body.
+func createdID(resp *http.Response) string {
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return ""
+	}
+
+	var created struct {
+		Data struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	_ = json.Unmarshal(body, &created)
+	return created.Data.ID
+}
@@ -0,0 +1,180 @@
+package tama_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	tama "github.com/upmaru/tama-go"
+	"github.com/upmaru/tama-go/memory"
+	"github.com/upmaru/tama-go/sensory"
+)
+
+// auditServer serves a source with a credential, creates prompts, has no
+// prompts to get and rejects updates of prompts.
+func auditServer(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	switch {
+	case r.Method == http.MethodDelete:
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodPost:
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"data":{"id":"prompt-new","name":"Tone"}}`))
+	case r.Method == http.MethodGet && strings.Contains(r.URL.Path, "/prompts/"):
+		http.NotFound(w, r)
+	case r.Method == http.MethodPatch && strings.Contains(r.URL.Path, "/prompts/"):
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		_, _ = w.Write([]byte(`{"errors":{"content":["can't be blank"]}}`))
+	default:
+		_, _ = w.Write([]byte(`{"data":{"id":"source-123","name":"Mistral",` +
+			`"endpoint":"https://api.mistral.ai/v1","credential":{"api_key":"old-secret"}}}`))
+	}
+}
+
+func TestAuditLog(t *testing.T) {
+	server := createMockServer(t, auditServer)
+	defer server.Close()
+
+	var log bytes.Buffer
+	client := tama.NewClient(tama.Config{
+		BaseURL: server.URL,
+		APIKey:  "test-key",
+		Audit:   &tama.AuditConfig{Sink: tama.NewJSONLAuditSink(&log), Actor: "sync-worker", Prior: true},
+	})
+
+	if _, err := client.Sensory.GetSource("source-123"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	source, err := client.Sensory.UpdateSource("source-123", sensory.UpdateSourceRequest{
+		Source: sensory.UpdateSourceData{Credential: &sensory.SourceCredential{APIKey: "new-secret"}},
+	})
+	if err != nil || source.ID != "source-123" {
+		t.Fatalf("Unexpected result: %+v, %v", source, err)
+	}
+	prompt, err := client.Memory.CreatePromptWithContext(tama.WithActor(context.Background(), "alice"), "space-123",
+		memory.CreatePromptRequest{Prompt: memory.PromptRequestData{Name: "Tone", Content: "Be brief.", Role: "system"}})
+	if err != nil || prompt.ID != "prompt-new" {
+		t.Fatalf("Unexpected result: %+v, %v", prompt, err)
+	}
+	if _, err := client.Memory.UpdatePrompt("prompt-new", memory.UpdatePromptRequest{}); err == nil {
+		t.Fatal("Expected error, got nil")
+	}
+
+	if strings.Contains(log.String(), "secret") {
+		t.Errorf("Expected secrets to be redacted:\n%s", log.String())
+	}
+
+	var records []tama.AuditRecord
+	for _, line := range strings.Split(strings.TrimSpace(log.String()), "\n") {
+		var record tama.AuditRecord
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("Invalid record %q: %v", line, err)
+		}
+		records = append(records, record)
+	}
+	if len(records) != 3 {
+		t.Fatalf("Expected a record per mutation, got %d:\n%s", len(records), log.String())
+	}
+
+	update := records[0]
+	if update.Operation != "sensory.UpdateSource" || update.Actor != "sync-worker" ||
+		update.ResourceID != "source-123" || update.Result != tama.AuditSuccess || update.StatusCode != http.StatusOK ||
+		update.Time.IsZero() || update.Path != "/provision/sensory/sources/source-123" {
+		t.Errorf("Unexpected update record: %+v", update)
+	}
+	if !strings.Contains(string(update.Request), `"api_key":"[REDACTED]"`) ||
+		!strings.Contains(string(update.Prior), `"endpoint":"https://api.mistral.ai/v1"`) {
+		t.Errorf("Expected the redacted request and prior state, got %s and %s", update.Request, update.Prior)
+	}
+
+	create := records[1]
+	if create.Operation != "memory.CreatePrompt" || create.Actor != "alice" || create.ResourceID != "prompt-new" ||
+		create.ParentID != "space-123" || create.Prior != nil || !strings.Contains(string(create.Request), "Be brief.") {
+		t.Errorf("Unexpected create record: %+v", create)
+	}
+
+	failed := records[2]
+	if failed.Result != tama.AuditFailure || failed.StatusCode != http.StatusUnprocessableEntity ||
+		failed.Error == "" || failed.Prior != nil || failed.PriorError == "" {
+		t.Errorf("Unexpected failure record: %+v", failed)
+	}
+}
+
+func TestAuditPriorBypassesCache(t *testing.T) {
+	var version atomic.Int32
+	version.Store(1)
+	server := createMockServer(t, func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprintf(w, `{"data":{"id":"source-123","name":"v%d"}}`, version.Load())
+	})
+	defer server.Close()
+
+	var log bytes.Buffer
+	client := tama.NewClient(tama.Config{
+		BaseURL: server.URL,
+		APIKey:  "test-key",
+		Cache:   &tama.CacheConfig{DefaultTTL: time.Hour},
+		Audit:   &tama.AuditConfig{Sink: tama.NewJSONLAuditSink(&log), Prior: true},
+	})
+
+	if _, err := client.Sensory.GetSource("source-123"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	// Another client changes the source after it was cached.
+	version.Store(2)
+	update := sensory.UpdateSourceRequest{Source: sensory.UpdateSourceData{Name: "v3"}}
+	if _, err := client.Sensory.UpdateSource("source-123", update); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var record tama.AuditRecord
+	if err := json.Unmarshal(log.Bytes(), &record); err != nil {
+		t.Fatalf("Invalid record %q: %v", log.String(), err)
+	}
+	if !strings.Contains(string(record.Prior), `"name":"v2"`) {
+		t.Errorf("Expected the prior state from the API, got %s", record.Prior)
+	}
+}
+
+func TestAuditLogFile(t *testing.T) {
+	server := createMockServer(t, auditServer)
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	for range 2 {
+		sink, err := tama.OpenJSONLAuditSink(path)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		client := tama.NewClient(tama.Config{
+			BaseURL: server.URL,
+			APIKey:  "test-key",
+			Audit:   &tama.AuditConfig{Sink: sink},
+		})
+		if err := client.Sensory.DeleteSource("source-123"); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if err := sink.Close(); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if lines := strings.Count(string(data), "\n"); lines != 2 {
+		t.Errorf("Expected records to be appended, got %d lines:\n%s", lines, data)
+	}
+	if !strings.Contains(string(data), `"operation":"sensory.DeleteSource"`) || strings.Contains(string(data), "prior") {
+		t.Errorf("Unexpected records:\n%s", data)
+	}
+}
@@ -0,0 +1,276 @@
+package tama
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/upmaru/tama-go/internal/transport"
+)
+
+const (
+	// DefaultBreakerFailureThreshold is the default number of consecutive failures that opens a circuit.
+	DefaultBreakerFailureThreshold = 5
+	// DefaultBreakerOpenTimeout is the default time a circuit stays open before it lets a probe through.
+	DefaultBreakerOpenTimeout = 30 * time.Second
+	// DefaultBreakerHalfOpenRequests is the default number of probes allowed while a circuit is half-open.
+	DefaultBreakerHalfOpenRequests = 1
+)
+
+// ErrCircuitOpen is returned, wrapped in a *CircuitOpenError, when a call is
+// rejected because the circuit breaker for its service is open.
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// CircuitOpenError is returned when a call fails fast because the circuit
+// breaker for its service is open.
+type CircuitOpenError struct {
+	// Service is the service whose circuit is open, e.g. "neural".
+	Service string
+	// Until is when the circuit will let a probe request through.
+	Until time.Time
+}
+
+func (e *CircuitOpenError) Error() string {
+	return fmt.Sprintf("circuit breaker for %s service is open until %s", e.Service, e.Until.Format(time.RFC3339))
+}
+
+// Is reports whether target is ErrCircuitOpen.
+func (e *CircuitOpenError) Is(target error) bool {
+	return target == ErrCircuitOpen
+}
+
+// BreakerState is the state of a circuit breaker.
+type BreakerState int
+
+const (
+	// BreakerClosed lets every call through.
+	BreakerClosed BreakerState = iota
+	// BreakerOpen rejects every call.
+	BreakerOpen
+	// BreakerHalfOpen lets a limited number of probe calls through.
+	BreakerHalfOpen
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	default:
+		return fmt.Sprintf("BreakerState(%d)", int(s))
+	}
+}
+
+// BreakerSettings configures the circuit breaker of a single service.
+type BreakerSettings struct {
+	// FailureThreshold is the number of consecutive failures that opens the
+	// circuit. Zero means DefaultBreakerFailureThreshold.
+	FailureThreshold int
+	// OpenTimeout is how long the circuit stays open before it moves to
+	// half-open. Zero means DefaultBreakerOpenTimeout.
+	OpenTimeout time.Duration
+	// HalfOpenRequests is the number of probe calls allowed while half-open.
+	// Zero means DefaultBreakerHalfOpenRequests.
+	HalfOpenRequests int
+}
+
+// CircuitBreakerConfig enables a circuit breaker per service. Calls that fail
+// with a connection error or a 429 or 5xx status count as failures; a call
+// with retries counts once. Calls refused by the client's own rate limiter do
+// not count.
+type CircuitBreakerConfig struct {
+	// Default applies to every service without an entry in Services.
+	Default BreakerSettings
+	// Services overrides the settings per service ("neural", "sensory", "memory").
+	Services map[string]BreakerSettings
+	// OnStateChange, if set, is called after a circuit changes state.
+	OnStateChange func(service string, from, to BreakerState)
+}
+
+// withDefaults returns a copy of the settings with zero values replaced by defaults.
+func (s BreakerSettings) withDefaults() BreakerSettings {
+	if s.FailureThreshold == 0 {
+		s.FailureThreshold = DefaultBreakerFailureThreshold
+	}
+	if s.OpenTimeout == 0 {
+		s.OpenTimeout = DefaultBreakerOpenTimeout
+	}
+	if s.HalfOpenRequests == 0 {
+		s.HalfOpenRequests = DefaultBreakerHalfOpenRequests
+	}
+	return s
+}
+
+// circuitBreaker tracks the state of one service.
+type circuitBreaker struct {
+	settings BreakerSettings
+	mu       sync.Mutex
+	state    BreakerState
+	failures int
+	probes   int
+	openedAt time.Time
+}
+
+// allow reports whether a call may proceed. It returns the state transition
+// caused by the check, if any.
+func (b *circuitBreaker) allow(now time.Time) (bool, BreakerState, BreakerState) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	from := b.state
+	if b.state == BreakerOpen && now.Sub(b.openedAt) >= b.settings.OpenTimeout {
+		b.state = BreakerHalfOpen
+		b.probes = 0
+	}
+
+	switch b.state {
+	case BreakerOpen:
+		return false, from, b.state
+	case BreakerHalfOpen:
+		if b.probes >= b.settings.HalfOpenRequests {
+			return false, from, b.state
+		}
+		b.probes++
+	case BreakerClosed:
+	}
+	return true, from, b.state
+}
+
+// record registers the outcome of a call and returns the resulting state transition.
+func (b *circuitBreaker) record(success bool, now time.Time) (BreakerState, BreakerState) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	from := b.state
+	switch {
+	case success:
+		b.state = BreakerClosed
+		b.failures = 0
+	case b.state == BreakerHalfOpen:
+		b.state = BreakerOpen
+		b.openedAt = now
+	default:
+		b.failures++
+		if b.failures >= b.settings.FailureThreshold {
+			b.state = BreakerOpen
+			b.openedAt = now
+		}
+	}
+	return from, b.state
+}
+
+// release gives back a half-open probe slot for a call whose outcome is unknown.
+func (b *circuitBreaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == BreakerHalfOpen && b.probes > 0 {
+		b.probes--
+	}
+}
+
+// until returns when an open circuit will let a probe through.
+func (b *circuitBreaker) until() time.Time {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.openedAt.Add(b.settings.OpenTimeout)
+}
+
+// current returns the current state of the circuit.
+func (b *circuitBreaker) current() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.state
+}
+
+// breakerTransport is an http.RoundTripper that guards each service with a circuit breaker.
+type breakerTransport struct {
+	next     http.RoundTripper
+	config   CircuitBreakerConfig
+	mu       sync.Mutex
+	breakers map[string]*circuitBreaker
+}
+
+// newBreakerTransport wraps next with the given circuit breaker configuration.
+func newBreakerTransport(next http.RoundTripper, config CircuitBreakerConfig) *breakerTransport {
+	return &breakerTransport{
+		next:     next,
+		config:   config,
+		breakers: make(map[string]*circuitBreaker),
+	}
+}
+
+// breaker returns the circuit breaker for service, creating it on first use.
+func (t *breakerTransport) breaker(service string) *circuitBreaker {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	b, ok := t.breakers[service]
+	if !ok {
+		settings, found := t.config.Services[service]
+		if !found {
+			settings = t.config.Default
+		}
+		b = &circuitBreaker{settings: settings.withDefaults()}
+		t.breakers[service] = b
+	}
+	return b
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *breakerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var service string
+	if call := transport.FromContext(req.Context()); call != nil {
+		service = call.Service()
+	}
+
+	b := t.breaker(service)
+	allowed, from, to := b.allow(time.Now())
+	t.notify(service, from, to)
+	if !allowed {
+		return nil, &CircuitOpenError{Service: service, Until: b.until()}
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	var limited *RateLimitedError
+	if err != nil && (callerGaveUp(req.Context()) || errors.As(err, &limited) && limited.Local) {
+		// The caller gave up or the call was never sent; that says nothing
+		// about the health of the API.
+		b.release()
+		return nil, err
+	}
+
+	from, to = b.record(err == nil && !isServerFailure(resp.StatusCode), time.Now())
+	t.notify(service, from, to)
+
+	return resp, err
+}
+
+// notify reports a state transition to the configured callback.
+func (t *breakerTransport) notify(service string, from, to BreakerState) {
+	if from != to && t.config.OnStateChange != nil {
+		t.config.OnStateChange(service, from, to)
+	}
+}
+
+// isServerFailure reports whether a status code indicates the API is unhealthy.
+func isServerFailure(statusCode int) bool {
+	return statusCode == http.StatusTooManyRequests || statusCode >= http.StatusInternalServerError
+}
+
+// BreakerState returns the state of the circuit breaker for the named service
+// ("neural", "sensory" or "memory"). It returns BreakerClosed when no circuit
+// breaker is configured.
+func (c *Client) BreakerState(service string) BreakerState {
+	if c.breaker == nil {
+		return BreakerClosed
+	}
+	return c.breaker.breaker(service).current()
+}
@@ -0,0 +1,190 @@
+package tama_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	tama "github.com/upmaru/tama-go"
+)
+
+type stateChange struct {
+	service  string
+	from, to tama.BreakerState
+}
+
+func TestCircuitBreakerOpensAndFailsFast(t *testing.T) {
+	var calls atomic.Int32
+	server := createMockServer(t, func(w http.ResponseWriter, _ *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	defer server.Close()
+
+	var mu sync.Mutex
+	var changes []stateChange
+	client := tama.NewClient(tama.Config{
+		BaseURL: server.URL,
+		APIKey:  "test-key",
+		CircuitBreaker: &tama.CircuitBreakerConfig{
+			Default: tama.BreakerSettings{FailureThreshold: 2, OpenTimeout: time.Hour},
+			OnStateChange: func(service string, from, to tama.BreakerState) {
+				mu.Lock()
+				defer mu.Unlock()
+				changes = append(changes, stateChange{service, from, to})
+			},
+		},
+	})
+
+	for range 2 {
+		if _, err := client.Neural.GetSpace("space-123"); err == nil {
+			t.Fatal("Expected error from unavailable server")
+		}
+	}
+
+	_, err := client.Neural.GetSpace("space-123")
+	if !errors.Is(err, tama.ErrCircuitOpen) {
+		t.Fatalf("Expected ErrCircuitOpen, got %v", err)
+	}
+
+	var openErr *tama.CircuitOpenError
+	if !errors.As(err, &openErr) || openErr.Service != "neural" {
+		t.Errorf("Expected CircuitOpenError for neural service, got %v", err)
+	}
+
+	if calls.Load() != 2 {
+		t.Errorf("Expected open circuit to stop requests reaching the server, got %d calls", calls.Load())
+	}
+
+	if client.BreakerState("neural") != tama.BreakerOpen {
+		t.Errorf("Expected neural circuit to be open, got %s", client.BreakerState("neural"))
+	}
+
+	if client.BreakerState("memory") != tama.BreakerClosed {
+		t.Errorf("Expected memory circuit to stay closed, got %s", client.BreakerState("memory"))
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(changes) != 1 || changes[0] != (stateChange{"neural", tama.BreakerClosed, tama.BreakerOpen}) {
+		t.Errorf("Expected a single closed -> open transition for neural, got %v", changes)
+	}
+}
+
+func TestCircuitBreakerHalfOpenRecovers(t *testing.T) {
+	var healthy atomic.Bool
+	server := createMockServer(t, func(w http.ResponseWriter, _ *http.Request) {
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+	defer server.Close()
+
+	var changes []stateChange
+	client := tama.NewClient(tama.Config{
+		BaseURL: server.URL,
+		APIKey:  "test-key",
+		CircuitBreaker: &tama.CircuitBreakerConfig{
+			Services: map[string]tama.BreakerSettings{
+				"sensory": {FailureThreshold: 1, OpenTimeout: 20 * time.Millisecond},
+			},
+			OnStateChange: func(service string, from, to tama.BreakerState) {
+				changes = append(changes, stateChange{service, from, to})
+			},
+		},
+	})
+
+	if err := client.Sensory.DeleteSource("source-1"); err == nil {
+		t.Fatal("Expected error from failing server")
+	}
+
+	if err := client.Sensory.DeleteSource("source-1"); !errors.Is(err, tama.ErrCircuitOpen) {
+		t.Fatalf("Expected ErrCircuitOpen, got %v", err)
+	}
+
+	healthy.Store(true)
+	time.Sleep(30 * time.Millisecond)
+
+	if err := client.Sensory.DeleteSource("source-1"); err != nil {
+		t.Fatalf("Expected probe to succeed, got %v", err)
+	}
+
+	expected := []stateChange{
+		{"sensory", tama.BreakerClosed, tama.BreakerOpen},
+		{"sensory", tama.BreakerOpen, tama.BreakerHalfOpen},
+		{"sensory", tama.BreakerHalfOpen, tama.BreakerClosed},
+	}
+	if len(changes) != len(expected) {
+		t.Fatalf("Expected transitions %v, got %v", expected, changes)
+	}
+	for i := range expected {
+		if changes[i] != expected[i] {
+			t.Errorf("Expected transition %v, got %v", expected[i], changes[i])
+		}
+	}
+}
+
+func TestCircuitBreakerIgnoresClientErrors(t *testing.T) {
+	server := createMockServer(t, func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	defer server.Close()
+
+	client := tama.NewClient(tama.Config{
+		BaseURL:        server.URL,
+		APIKey:         "test-key",
+		CircuitBreaker: &tama.CircuitBreakerConfig{Default: tama.BreakerSettings{FailureThreshold: 1}},
+	})
+
+	for range 3 {
+		if err := client.Memory.DeletePrompt("missing"); errors.Is(err, tama.ErrCircuitOpen) {
+			t.Fatal("Expected 404 responses not to open the circuit")
+		}
+	}
+}
+
+func TestCircuitBreakerIgnoresLocalRateLimit(t *testing.T) {
+	var calls atomic.Int32
+	server := createMockServer(t, func(w http.ResponseWriter, _ *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusNoContent)
+	})
+	defer server.Close()
+
+	client := tama.NewClient(tama.Config{
+		BaseURL:        server.URL,
+		APIKey:         "test-key",
+		RateLimit:      &tama.RateLimit{RequestsPerSecond: 1, Burst: 1},
+		CircuitBreaker: &tama.CircuitBreakerConfig{Default: tama.BreakerSettings{FailureThreshold: 2}},
+	})
+
+	for i := range 4 {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		err := client.Memory.DeletePromptWithContext(ctx, "prompt-1")
+		cancel()
+
+		if i == 0 {
+			if err != nil {
+				t.Fatalf("Expected the first call to succeed, got %v", err)
+			}
+			continue
+		}
+		var limited *tama.RateLimitedError
+		if !errors.As(err, &limited) || !limited.Local {
+			t.Fatalf("Call %d: expected a local RateLimitedError, got %v", i, err)
+		}
+	}
+
+	if calls.Load() != 1 {
+		t.Errorf("Expected throttled calls not to reach the server, got %d calls", calls.Load())
+	}
+	if client.BreakerState("memory") != tama.BreakerClosed {
+		t.Errorf("Expected client-side throttling to leave the circuit closed, got %s", client.BreakerState("memory"))
+	}
+}
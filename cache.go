@@ -0,0 +1,282 @@
+package tama
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/upmaru/tama-go/internal/transport"
+)
+
+// CacheConfig enables an in-memory read-through cache for Get calls.
+//
+// Cached resources are invalidated when the same client updates, replaces or
+// deletes them, and when it creates a resource under them, e.g. a source in a
+// cached space. Concurrent identical Get calls are collapsed into one request.
+type CacheConfig struct {
+	// TTL sets how long resources of each type are cached, keyed by "space",
+	// "source", "model", "limit" or "prompt". Types without an entry use
+	// DefaultTTL.
+	TTL map[string]time.Duration
+	// DefaultTTL applies to resource types without an entry in TTL. Zero means
+	// they are not cached.
+	DefaultTTL time.Duration
+}
+
+// CacheStats reports the effectiveness of the cache.
+type CacheStats struct {
+	// Hits counts Get calls served from the cache or by sharing the
+	// response of an identical call in flight.
+	Hits uint64
+	// Misses counts Get calls that had to be sent to the API.
+	Misses uint64
+	// Invalidations counts entries removed because of a mutation.
+	Invalidations uint64
+	// Entries is the number of resources currently cached.
+	Entries int
+}
+
+// cachedResponse is a response held by the cache or shared between identical calls.
+type cachedResponse struct {
+	status  string
+	code    int
+	header  http.Header
+	body    []byte
+	expires time.Time
+}
+
+// response returns a new *http.Response with the cached content.
+func (r *cachedResponse) response(req *http.Request) *http.Response {
+	return &http.Response{
+		Status:        r.status,
+		StatusCode:    r.code,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        r.header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(r.body)),
+		ContentLength: int64(len(r.body)),
+		Request:       req,
+	}
+}
+
+// flight is a Get call in progress that identical calls wait for.
+type flight struct {
+	done   chan struct{}
+	result *cachedResponse
+	err    error
+	// stale is set when the resource is invalidated while the call is in flight.
+	stale bool
+	// abandoned is set when the call that started the flight was cancelled.
+	abandoned bool
+}
+
+// cacheTransport is an http.RoundTripper that serves Get calls from memory.
+type cacheTransport struct {
+	next          http.RoundTripper
+	config        CacheConfig
+	mu            sync.Mutex
+	entries       map[string]*cachedResponse
+	flights       map[string]*flight
+	lastSweep     time.Time
+	hits          atomic.Uint64
+	misses        atomic.Uint64
+	invalidations atomic.Uint64
+}
+
+// newCacheTransport wraps next with the given cache configuration.
+func newCacheTransport(next http.RoundTripper, config CacheConfig) *cacheTransport {
+	return &cacheTransport{
+		next:    next,
+		config:  config,
+		entries: make(map[string]*cachedResponse),
+		flights: make(map[string]*flight),
+	}
+}
+
+// ttl returns how long resources of the given type are cached.
+func (t *cacheTransport) ttl(resource string) time.Duration {
+	if ttl, ok := t.config.TTL[resource]; ok {
+		return ttl
+	}
+	return t.config.DefaultTTL
+}
+
+// cacheBypassKey marks the context of a Get call that must be sent to the API
+// rather than served from the cache, e.g. to record the current state of a
+// resource before it is changed.
+type cacheBypassKey struct{}
+
+// RoundTrip implements http.RoundTripper.
+func (t *cacheTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	call := transport.FromContext(req.Context())
+	if call == nil || req.Context().Value(cacheBypassKey{}) != nil {
+		return t.next.RoundTrip(req)
+	}
+
+	if req.Method != http.MethodGet {
+		resp, err := t.next.RoundTrip(req)
+		if call.ResourceID != "" {
+			t.invalidate(cacheKey(call.Resource(), call.ResourceID))
+		}
+		if call.ParentID != "" {
+			t.invalidate(cacheKey(call.ParentResource(), call.ParentID))
+		}
+		return resp, err
+	}
+
+	ttl := t.ttl(call.Resource())
+	if call.ResourceID == "" || ttl <= 0 {
+		return t.next.RoundTrip(req)
+	}
+	return t.get(req, cacheKey(call.Resource(), call.ResourceID), ttl)
+}
+
+// get serves a cacheable Get call from the cache, from an identical call in
+// flight, or by sending it.
+func (t *cacheTransport) get(req *http.Request, key string, ttl time.Duration) (*http.Response, error) {
+	now := time.Now()
+
+	t.mu.Lock()
+	if entry, ok := t.entries[key]; ok && now.Before(entry.expires) {
+		t.mu.Unlock()
+		t.hits.Add(1)
+		return entry.response(req), nil
+	}
+	if f, ok := t.flights[key]; ok {
+		t.mu.Unlock()
+		return t.wait(req, key, ttl, f)
+	}
+
+	f := &flight{done: make(chan struct{})}
+	t.flights[key] = f
+	t.mu.Unlock()
+
+	t.misses.Add(1)
+	f.result, f.err = t.fetch(req, ttl)
+
+	t.mu.Lock()
+	delete(t.flights, key)
+	f.abandoned = req.Context().Err() != nil
+	if f.err == nil && f.result.code == http.StatusOK && !f.stale {
+		t.entries[key] = f.result
+		t.sweep(now)
+	}
+	t.mu.Unlock()
+	close(f.done)
+
+	if f.err != nil {
+		return nil, f.err
+	}
+	return f.result.response(req), nil
+}
+
+// wait returns the outcome of an identical call in flight. If the caller
+// that started the flight gave up, the waiting call is sent again.
+func (t *cacheTransport) wait(req *http.Request, key string, ttl time.Duration, f *flight) (*http.Response, error) {
+	select {
+	case <-f.done:
+	case <-req.Context().Done():
+		return nil, req.Context().Err()
+	}
+
+	if f.err != nil && f.abandoned {
+		return t.get(req, key, ttl)
+	}
+	if f.err != nil {
+		return nil, f.err
+	}
+	t.hits.Add(1)
+	return f.result.response(req), nil
+}
+
+// fetch sends req and captures its response.
+func (t *cacheTransport) fetch(req *http.Request, ttl time.Duration) (*cachedResponse, error) {
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	return &cachedResponse{
+		status:  resp.Status,
+		code:    resp.StatusCode,
+		header:  resp.Header,
+		body:    body,
+		expires: time.Now().Add(ttl),
+	}, nil
+}
+
+// invalidate removes a cached resource and stops a call in flight from
+// storing a response that may predate the mutation.
+func (t *cacheTransport) invalidate(key string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if f, ok := t.flights[key]; ok {
+		f.stale = true
+	}
+	if _, ok := t.entries[key]; ok {
+		delete(t.entries, key)
+		t.invalidations.Add(1)
+	}
+}
+
+// purge removes every entry.
+func (t *cacheTransport) purge() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	clear(t.entries)
+}
+
+// sweep removes expired entries, at most once per minute. It must be called
+// with t.mu held.
+func (t *cacheTransport) sweep(now time.Time) {
+	if now.Sub(t.lastSweep) < time.Minute {
+		return
+	}
+	t.lastSweep = now
+
+	for key, entry := range t.entries {
+		if !now.Before(entry.expires) {
+			delete(t.entries, key)
+		}
+	}
+}
+
+// stats returns a snapshot of the cache statistics.
+func (t *cacheTransport) stats() CacheStats {
+	t.mu.Lock()
+	entries := len(t.entries)
+	t.mu.Unlock()
+
+	return CacheStats{
+		Hits:          t.hits.Load(),
+		Misses:        t.misses.Load(),
+		Invalidations: t.invalidations.Load(),
+		Entries:       entries,
+	}
+}
+
+// cacheKey identifies a resource in the cache.
+func cacheKey(resource, id string) string {
+	return resource + ":" + id
+}
+
+// CacheStats returns the statistics of the client's cache. It returns zero
+// statistics when no cache is configured.
+func (c *Client) CacheStats() CacheStats {
+	if c.cache == nil {
+		return CacheStats{}
+	}
+	return c.cache.stats()
+}
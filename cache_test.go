@@ -0,0 +1,182 @@
+package tama_test
+
+import (
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	tama "github.com/upmaru/tama-go"
+	"github.com/upmaru/tama-go/neural"
+	"github.com/upmaru/tama-go/sensory"
+)
+
+func TestCacheServesRepeatedGets(t *testing.T) {
+	var calls atomic.Int32
+	server := createMockServer(t, func(w http.ResponseWriter, _ *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("ETag", `"v1"`)
+		_, _ = w.Write([]byte(`{"data":{"id":"space-123","name":"Test Space","type":"root"}}`))
+	})
+	defer server.Close()
+
+	client := tama.NewClient(tama.Config{
+		BaseURL: server.URL,
+		APIKey:  "test-key",
+		Cache:   &tama.CacheConfig{TTL: map[string]time.Duration{"space": time.Minute}},
+	})
+
+	for range 3 {
+		space, err := client.Neural.GetSpace("space-123")
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if space.Name != "Test Space" || space.ETag != `"v1"` {
+			t.Errorf("Unexpected cached space: %+v", space)
+		}
+	}
+
+	if calls.Load() != 1 {
+		t.Errorf("Expected 1 request, got %d", calls.Load())
+	}
+
+	stats := client.CacheStats()
+	if stats.Hits != 2 || stats.Misses != 1 || stats.Entries != 1 {
+		t.Errorf("Unexpected cache stats: %+v", stats)
+	}
+}
+
+func TestCacheSkipsResourcesWithoutTTL(t *testing.T) {
+	var calls atomic.Int32
+	server := createMockServer(t, func(w http.ResponseWriter, _ *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"data":{"id":"model-123","identifier":"gpt"}}`))
+	})
+	defer server.Close()
+
+	client := tama.NewClient(tama.Config{
+		BaseURL: server.URL,
+		APIKey:  "test-key",
+		Cache:   &tama.CacheConfig{TTL: map[string]time.Duration{"space": time.Minute}},
+	})
+
+	for range 2 {
+		if _, err := client.Sensory.GetModel("model-123"); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+
+	if calls.Load() != 2 {
+		t.Errorf("Expected models not to be cached, got %d requests", calls.Load())
+	}
+}
+
+func TestCacheInvalidatesOnMutations(t *testing.T) {
+	var mu sync.Mutex
+	requests := map[string]int{}
+	server := createMockServer(t, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests[r.Method+" "+r.URL.Path]++
+		mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/provision/sensory/spaces/space-123/sources" {
+			_, _ = w.Write([]byte(`{"data":{"id":"source-1","name":"Source"}}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":{"id":"space-123","name":"Test Space","type":"root"}}`))
+	})
+	defer server.Close()
+
+	client := tama.NewClient(tama.Config{
+		BaseURL: server.URL,
+		APIKey:  "test-key",
+		Cache:   &tama.CacheConfig{DefaultTTL: time.Minute},
+	})
+
+	get := func() {
+		t.Helper()
+		if _, err := client.Neural.GetSpace("space-123"); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+
+	get()
+	get()
+	if _, err := client.Neural.UpdateSpace("space-123", neural.UpdateSpaceRequest{
+		Space: neural.UpdateSpaceData{Name: "Renamed"},
+	}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	get()
+	if _, err := client.Sensory.CreateSource("space-123", sensory.CreateSourceRequest{
+		Source: sensory.SourceRequestData{
+			Name:       "Source",
+			Type:       "model",
+			Endpoint:   "https://api.example.com",
+			Credential: sensory.SourceCredential{APIKey: "key"},
+		},
+	}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	get()
+	get()
+
+	if n := requests["GET /provision/neural/spaces/space-123"]; n != 3 {
+		t.Errorf("Expected 3 space fetches (initial, after update, after create), got %d", n)
+	}
+	if stats := client.CacheStats(); stats.Invalidations != 2 {
+		t.Errorf("Expected 2 invalidations, got %+v", stats)
+	}
+}
+
+func TestCacheCollapsesConcurrentGets(t *testing.T) {
+	var calls atomic.Int32
+	release := make(chan struct{})
+	server := createMockServer(t, func(w http.ResponseWriter, _ *http.Request) {
+		calls.Add(1)
+		<-release
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"data":{"id":"prompt-123","name":"Prompt"}}`))
+	})
+	defer server.Close()
+
+	client := tama.NewClient(tama.Config{
+		BaseURL: server.URL,
+		APIKey:  "test-key",
+		Cache:   &tama.CacheConfig{DefaultTTL: time.Minute},
+	})
+
+	const callers = 10
+	var wg sync.WaitGroup
+	errs := make(chan error, callers)
+	for range callers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := client.Memory.GetPrompt("prompt-123")
+			errs <- err
+		}()
+	}
+
+	// Give the callers time to join the flight before the server answers.
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+	}
+	if calls.Load() != 1 {
+		t.Errorf("Expected concurrent gets to share 1 request, got %d", calls.Load())
+	}
+	if stats := client.CacheStats(); stats.Hits+stats.Misses != callers || stats.Misses != 1 {
+		t.Errorf("Unexpected cache stats: %+v", stats)
+	}
+}
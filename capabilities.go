@@ -0,0 +1,261 @@
+package tama
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/upmaru/tama-go/internal/transport"
+)
+
+// APIVersionHeader is the request header that carries Config.APIVersion.
+const APIVersionHeader = "Tama-Api-Version"
+
+// CapabilitiesPath is the endpoint that Client.Capabilities probes.
+const CapabilitiesPath = "/provision/capabilities"
+
+// CapabilityProbeBackoff is how long a client with Config.APIVersion set sends
+// calls without probing the server's capabilities first after a probe failed.
+const CapabilityProbeBackoff = 30 * time.Second
+
+// Features the server may advertise.
+const (
+	// FeatureConditionalRequests means the server honours If-Match on
+	// updates, replaces and deletes. Calls made with WithIfMatch require it.
+	FeatureConditionalRequests = "conditional-requests"
+	// FeatureIdempotencyKeys means the server deduplicates creates by their
+	// Idempotency-Key header.
+	FeatureIdempotencyKeys = "idempotency-keys"
+)
+
+// Capabilities describes what the server supports, as reported by
+// Client.Capabilities.
+type Capabilities struct {
+	// Advertised is false if the server does not support capability
+	// discovery. Nothing is known about it then, and no call is rejected.
+	Advertised bool `json:"-"`
+	// APIVersion is the API version the server answered with.
+	APIVersion string `json:"api_version"`
+	// Features lists the optional features the server supports, such as
+	// FeatureConditionalRequests.
+	Features []string `json:"features"`
+	// Endpoints lists the endpoints the server supports as a method and a
+	// route, e.g. "GET /provision/neural/spaces/{id}".
+	Endpoints []string `json:"endpoints"`
+}
+
+// HasFeature reports whether the server supports the named feature. It
+// returns true if the server does not advertise its capabilities.
+func (c *Capabilities) HasFeature(feature string) bool {
+	return !c.Advertised || slices.Contains(c.Features, feature)
+}
+
+// HasEndpoint reports whether the server supports the endpoint with the given
+// method and route. It returns true if the server does not advertise its
+// capabilities.
+func (c *Capabilities) HasEndpoint(method, route string) bool {
+	return !c.Advertised || slices.Contains(c.Endpoints, method+" "+route)
+}
+
+// UnsupportedError is returned without contacting the server when a call needs
+// an endpoint or feature the server does not advertise.
+type UnsupportedError struct {
+	// Operation names the service method that was called, e.g. "sensory.CreateModel".
+	Operation string
+	// Endpoint is the unsupported endpoint, if any, e.g. "POST /provision/sensory/sources/{id}/models".
+	Endpoint string
+	// Feature is the unsupported feature, if any.
+	Feature string
+	// APIVersion is the API version the server reported.
+	APIVersion string
+}
+
+func (e *UnsupportedError) Error() string {
+	what := "endpoint " + e.Endpoint
+	if e.Feature != "" {
+		what = "feature " + e.Feature
+	}
+	if e.APIVersion != "" {
+		return fmt.Sprintf("%s is not supported by the server (API version %s): %s not available",
+			e.Operation, e.APIVersion, what)
+	}
+	return fmt.Sprintf("%s is not supported by the server: %s not available", e.Operation, what)
+}
+
+// capabilityCache holds the capabilities of a server once they are known.
+type capabilityCache struct {
+	// probe holds a token while a probe is in progress.
+	probe chan struct{}
+	mu    sync.RWMutex
+	caps  *Capabilities
+	// retryAt is when calls may probe again after a failed probe.
+	retryAt time.Time
+}
+
+func newCapabilityCache() *capabilityCache {
+	return &capabilityCache{probe: make(chan struct{}, 1)}
+}
+
+// load returns the cached capabilities, or nil if the server was not probed yet.
+func (c *capabilityCache) load() *Capabilities {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.caps
+}
+
+// store caches the capabilities.
+func (c *capabilityCache) store(caps *Capabilities) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.caps = caps
+}
+
+// fail remembers that a probe failed at now.
+func (c *capabilityCache) fail(now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.retryAt = now.Add(CapabilityProbeBackoff)
+}
+
+// backingOff reports whether calls must not probe at now because a recent
+// probe failed.
+func (c *capabilityCache) backingOff(now time.Time) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return now.Before(c.retryAt)
+}
+
+// Capabilities returns what the server supports. The server is probed on the
+// first call, or before the first service call if Config.APIVersion is set;
+// once a probe succeeds its result is cached for the lifetime of the client
+// and of clients derived from it for the same server. From then on calls that
+// need an endpoint or feature the server does not advertise fail with an
+// *UnsupportedError instead of being sent. A server that does not implement
+// capability discovery is reported with Advertised set to false, and no call
+// is rejected.
+func (c *Client) Capabilities(ctx context.Context) (*Capabilities, error) {
+	return c.loadCapabilities(ctx, false)
+}
+
+// loadCapabilities returns the cached capabilities, probing the server if
+// needed. A probe made before a call (auto) is skipped for
+// CapabilityProbeBackoff after a failed probe, so that calls during an outage
+// do not each wait for a failing probe first.
+func (c *Client) loadCapabilities(ctx context.Context, auto bool) (*Capabilities, error) {
+	if caps := c.capabilities.load(); caps != nil {
+		return caps, nil
+	}
+	if auto && c.capabilities.backingOff(time.Now()) {
+		return nil, nil
+	}
+
+	select {
+	case c.capabilities.probe <- struct{}{}:
+		defer func() { <-c.capabilities.probe }()
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	// Another caller may have finished a probe while this one waited.
+	if caps := c.capabilities.load(); caps != nil {
+		return caps, nil
+	}
+	if auto && c.capabilities.backingOff(time.Now()) {
+		return nil, nil
+	}
+
+	caps, err := c.probeCapabilities(ctx)
+	if err != nil {
+		if !callerGaveUp(ctx) {
+			c.capabilities.fail(time.Now())
+		}
+		return nil, err
+	}
+	c.capabilities.store(caps)
+	return caps, nil
+}
+
+// probeCapabilities asks the server for its capabilities.
+func (c *Client) probeCapabilities(ctx context.Context) (*Capabilities, error) {
+	call := transport.NewCall("tama.Capabilities", http.MethodGet, CapabilitiesPath, "")
+	ctx = transport.NewContext(ctx, call)
+
+	url := strings.TrimSuffix(c.baseURL, "/") + CapabilitiesPath
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to probe capabilities: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return &Capabilities{APIVersion: resp.Header.Get(APIVersionHeader)}, nil
+	case resp.StatusCode >= http.StatusBadRequest:
+		return nil, &Error{StatusCode: resp.StatusCode}
+	}
+
+	var body struct {
+		Data Capabilities `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("failed to decode capabilities: %w", err)
+	}
+	caps := &body.Data
+	caps.Advertised = true
+	if caps.APIVersion == "" {
+		caps.APIVersion = resp.Header.Get(APIVersionHeader)
+	}
+	return caps, nil
+}
+
+// checkCapabilities returns an *UnsupportedError if the server is known not to
+// support the call made with req. A client with an API version probes the
+// server before its first call. If that probe fails, calls are sent without a
+// check until a probe succeeds; calls probe again after
+// CapabilityProbeBackoff.
+func (c *Client) checkCapabilities(req *http.Request) error {
+	call := transport.FromContext(req.Context())
+	if call == nil || call.Route == CapabilitiesPath {
+		return nil
+	}
+
+	caps := c.capabilities.load()
+	if caps == nil {
+		c.mu.RLock()
+		version := c.headers.Get(APIVersionHeader)
+		c.mu.RUnlock()
+		if version != "" {
+			// A failed probe must not fail the call; its error surfaces
+			// through Capabilities.
+			caps, _ = c.loadCapabilities(req.Context(), true)
+		}
+	}
+	if caps == nil || !caps.Advertised {
+		return nil
+	}
+
+	unsupported := &UnsupportedError{Operation: call.Operation, APIVersion: caps.APIVersion}
+	if !caps.HasEndpoint(req.Method, call.Route) {
+		unsupported.Endpoint = req.Method + " " + call.Route
+		return unsupported
+	}
+	if ifMatch(req) != "" && !caps.HasFeature(FeatureConditionalRequests) {
+		unsupported.Feature = FeatureConditionalRequests
+		return unsupported
+	}
+	return nil
+}
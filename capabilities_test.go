@@ -0,0 +1,223 @@
+package tama_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	tama "github.com/upmaru/tama-go"
+	"github.com/upmaru/tama-go/neural"
+)
+
+const capabilitiesBody = `{"data":{
+	"api_version":"2025-01-01",
+	"features":["idempotency-keys"],
+	"endpoints":["GET /provision/neural/spaces/{id}","PATCH /provision/neural/spaces/{id}"]
+}}`
+
+// capabilityServer serves capabilitiesBody, or 404 if it is empty, and a space
+// for every other request. It counts the requests it receives.
+type capabilityServer struct {
+	body     string
+	probes   atomic.Int32
+	requests atomic.Int32
+	versions sync.Map
+}
+
+func (s *capabilityServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.versions.Store(r.Header.Get(tama.APIVersionHeader), true)
+	if r.URL.Path == tama.CapabilitiesPath {
+		s.probes.Add(1)
+		if s.body == "" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(s.body))
+		return
+	}
+	s.requests.Add(1)
+	spaceHandler(w, r)
+}
+
+func TestAPIVersionHeader(t *testing.T) {
+	srv := &capabilityServer{}
+	server := createMockServer(t, srv.ServeHTTP)
+	defer server.Close()
+
+	client := tama.NewClient(tama.Config{BaseURL: server.URL, APIKey: "test-key", APIVersion: "2025-01-01"})
+	if _, err := client.Neural.GetSpace("space-123"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, ok := srv.versions.Load("2025-01-01"); !ok {
+		t.Error("Expected the API version header to be sent")
+	}
+}
+
+func TestCapabilities(t *testing.T) {
+	srv := &capabilityServer{body: capabilitiesBody}
+	server := createMockServer(t, srv.ServeHTTP)
+	defer server.Close()
+
+	client := tama.NewClient(tama.Config{BaseURL: server.URL, APIKey: "test-key"})
+
+	var wg sync.WaitGroup
+	for range 5 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := client.Capabilities(context.Background()); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	caps, err := client.Capabilities(context.Background())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if srv.probes.Load() != 1 {
+		t.Errorf("Expected a single probe, got %d", srv.probes.Load())
+	}
+	if !caps.Advertised || caps.APIVersion != "2025-01-01" {
+		t.Errorf("Unexpected capabilities: %+v", caps)
+	}
+	if !caps.HasFeature(tama.FeatureIdempotencyKeys) || caps.HasFeature(tama.FeatureConditionalRequests) {
+		t.Errorf("Unexpected features: %v", caps.Features)
+	}
+
+	// Supported endpoints are called as usual.
+	if _, err := client.Neural.GetSpace("space-123"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// Unsupported endpoints and features fail without a request.
+	var unsupported *tama.UnsupportedError
+	err = client.Neural.DeleteSpace("space-123")
+	if !errors.As(err, &unsupported) || unsupported.Endpoint != "DELETE /provision/neural/spaces/{id}" {
+		t.Errorf("Expected UnsupportedError for the endpoint, got %v", err)
+	}
+	if unsupported != nil && (unsupported.Operation != "neural.DeleteSpace" || unsupported.APIVersion != "2025-01-01") {
+		t.Errorf("Unexpected error details: %+v", unsupported)
+	}
+
+	ctx := tama.WithIfMatch(context.Background(), `"v1"`)
+	_, err = client.Neural.UpdateSpaceWithContext(ctx, "space-123", neural.UpdateSpaceRequest{})
+	if !errors.As(err, &unsupported) || unsupported.Feature != tama.FeatureConditionalRequests {
+		t.Errorf("Expected UnsupportedError for conditional requests, got %v", err)
+	}
+
+	if srv.requests.Load() != 1 {
+		t.Errorf("Expected unsupported calls not to be sent, got %d requests", srv.requests.Load())
+	}
+
+	// Clients derived for the same server share the capabilities.
+	if _, err := client.WithAPIKey("tenant-key").Capabilities(context.Background()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if srv.probes.Load() != 1 {
+		t.Errorf("Expected derived clients to reuse the probe, got %d probes", srv.probes.Load())
+	}
+}
+
+func TestCapabilitiesProbedWithAPIVersion(t *testing.T) {
+	var fail atomic.Bool
+	var attempts atomic.Int32
+	srv := &capabilityServer{body: capabilitiesBody}
+	server := createMockServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == tama.CapabilitiesPath {
+			attempts.Add(1)
+			if fail.Load() {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+		}
+		srv.ServeHTTP(w, r)
+	})
+	defer server.Close()
+
+	config := tama.Config{BaseURL: server.URL, APIKey: "test-key", APIVersion: "2025-01-01"}
+	client := tama.NewClient(config)
+
+	var unsupported *tama.UnsupportedError
+	if err := client.Neural.DeleteSpace("space-123"); !errors.As(err, &unsupported) {
+		t.Fatalf("Expected UnsupportedError without calling Capabilities first, got %v", err)
+	}
+	if _, err := client.Neural.GetSpace("space-123"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if attempts.Load() != 1 || srv.requests.Load() != 1 {
+		t.Errorf("Expected 1 probe and 1 request, got %d and %d", attempts.Load(), srv.requests.Load())
+	}
+
+	// A failed probe lets calls through unchecked and is not repeated before
+	// every call.
+	fail.Store(true)
+	attempts.Store(0)
+	client = tama.NewClient(config)
+	for range 3 {
+		if err := client.Neural.DeleteSpace("space-123"); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	if attempts.Load() != 1 {
+		t.Errorf("Expected a single failed probe, got %d", attempts.Load())
+	}
+
+	// Asking explicitly still probes.
+	fail.Store(false)
+	if caps, err := client.Capabilities(context.Background()); err != nil || !caps.Advertised {
+		t.Fatalf("Expected a successful probe, got %+v, %v", caps, err)
+	}
+	if err := client.Neural.DeleteSpace("space-123"); !errors.As(err, &unsupported) {
+		t.Errorf("Expected UnsupportedError once the probe succeeded, got %v", err)
+	}
+}
+
+func TestCapabilitiesNotAdvertised(t *testing.T) {
+	srv := &capabilityServer{}
+	server := createMockServer(t, srv.ServeHTTP)
+	defer server.Close()
+
+	client := tama.NewClient(tama.Config{BaseURL: server.URL, APIKey: "test-key"})
+	caps, err := client.Capabilities(context.Background())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if caps.Advertised || !caps.HasEndpoint(http.MethodDelete, "/provision/neural/spaces/{id}") {
+		t.Errorf("Expected unknown capabilities to allow everything, got %+v", caps)
+	}
+	if err := client.Neural.DeleteSpace("space-123"); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestCapabilitiesProbeFailure(t *testing.T) {
+	var fail atomic.Bool
+	fail.Store(true)
+	srv := &capabilityServer{body: capabilitiesBody}
+	server := createMockServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if fail.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		srv.ServeHTTP(w, r)
+	})
+	defer server.Close()
+
+	client := tama.NewClient(tama.Config{BaseURL: server.URL, APIKey: "test-key"})
+	if _, err := client.Capabilities(context.Background()); err == nil {
+		t.Fatal("Expected the probe to fail")
+	}
+
+	// Failed probes are not cached.
+	fail.Store(false)
+	caps, err := client.Capabilities(context.Background())
+	if err != nil || !caps.Advertised {
+		t.Errorf("Expected a successful probe, got %+v, %v", caps, err)
+	}
+}
@@ -0,0 +1,376 @@
+package tama
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/upmaru/tama-go/internal/transport"
+)
+
+// DefaultCaptureSize is the number of attempts kept by StartCapture when no
+// size is given.
+const DefaultCaptureSize = 100
+
+// Capture is a recorded HTTP attempt. Headers and bodies are redacted like
+// debug output, so captures can be shared safely.
+type Capture struct {
+	// Operation names the service method that issued the call, e.g. "sensory.CreateModel".
+	Operation string
+	// Attempt is the number of the attempt within its call, starting at 1.
+	Attempt  int
+	Started  time.Time
+	Duration time.Duration
+
+	Method         string
+	URL            string
+	RequestHeader  http.Header
+	RequestBody    []byte
+	StatusCode     int
+	Status         string
+	ResponseHeader http.Header
+	ResponseBody   []byte
+	// Err is the error message if no response was received.
+	Err string
+}
+
+// captureBuffer is a ring buffer of the most recent captures.
+type captureBuffer struct {
+	mu      sync.Mutex
+	enabled bool
+	entries []Capture
+	next    int
+	full    bool
+}
+
+// start enables capturing and resizes the buffer, keeping the newest entries.
+func (b *captureBuffer) start(size int) {
+	if size <= 0 {
+		size = DefaultCaptureSize
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	entries := b.snapshot()
+	if len(entries) > size {
+		entries = entries[len(entries)-size:]
+	}
+	b.entries = append(make([]Capture, 0, size), entries...)
+	b.next, b.full = len(entries)%size, len(entries) == size
+	b.enabled = true
+}
+
+// stop disables capturing. The captured entries are kept.
+func (b *captureBuffer) stop() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.enabled = false
+}
+
+// active reports whether capturing is enabled.
+func (b *captureBuffer) active() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.enabled
+}
+
+// add records a capture, replacing the oldest one if the buffer is full.
+func (b *captureBuffer) add(capture Capture) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !b.enabled {
+		return
+	}
+	if !b.full && len(b.entries) < cap(b.entries) {
+		b.entries = append(b.entries, capture)
+	} else {
+		b.entries[b.next] = capture
+	}
+	b.next = (b.next + 1) % cap(b.entries)
+	b.full = b.full || b.next == 0
+}
+
+// snapshot returns the entries from oldest to newest. It must be called with
+// b.mu held.
+func (b *captureBuffer) snapshot() []Capture {
+	if !b.full {
+		return slices.Clone(b.entries)
+	}
+	return append(slices.Clone(b.entries[b.next:]), b.entries[:b.next]...)
+}
+
+// captures returns the entries from oldest to newest.
+func (b *captureBuffer) captures() []Capture {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.snapshot()
+}
+
+// clear removes all entries.
+func (b *captureBuffer) clear() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.entries, b.next, b.full = b.entries[:0], 0, false
+}
+
+// captureRequest starts recording an attempt if capturing is enabled. It
+// returns nil otherwise.
+func (c *Client) captureRequest(req *http.Request) *Capture {
+	if !c.captures.active() {
+		return nil
+	}
+
+	body, _ := readBody(req)
+	capture := &Capture{
+		Attempt:       1,
+		Started:       time.Now(),
+		Method:        req.Method,
+		URL:           req.URL.String(),
+		RequestHeader: redactHeader(req.Header),
+		RequestBody:   redactBody(body),
+	}
+	if call := transport.FromContext(req.Context()); call != nil {
+		capture.Operation, capture.Attempt = call.Operation, call.Attempts
+	}
+	return capture
+}
+
+// captureResponse completes and stores an attempt started by captureRequest.
+func (c *Client) captureResponse(capture *Capture, resp *http.Response, body []byte, err error) {
+	if capture == nil {
+		return
+	}
+
+	capture.Duration = time.Since(capture.Started)
+	if err != nil {
+		capture.Err = err.Error()
+	}
+	if resp != nil {
+		capture.StatusCode = resp.StatusCode
+		capture.Status = resp.Status
+		capture.ResponseHeader = redactHeader(resp.Header)
+		capture.ResponseBody = redactBody(body)
+	}
+	c.captures.add(*capture)
+}
+
+// StartCapture starts recording every HTTP attempt the client makes, keeping
+// the most recent size attempts; zero means DefaultCaptureSize. Calling it
+// while capturing resizes the buffer. Capturing costs a copy of every request
+// and response body, so enable it only while investigating a problem.
+func (c *Client) StartCapture(size int) {
+	c.captures.start(size)
+}
+
+// StopCapture stops recording. The attempts captured so far remain available.
+func (c *Client) StopCapture() {
+	c.captures.stop()
+}
+
+// ClearCaptures discards the captured attempts.
+func (c *Client) ClearCaptures() {
+	c.captures.clear()
+}
+
+// Captures returns the captured attempts from oldest to newest.
+func (c *Client) Captures() []Capture {
+	return c.captures.captures()
+}
+
+// ExportCurl writes the captured attempts as curl commands, one per attempt,
+// each preceded by a comment with the operation and outcome. The API key is
+// replaced by a reference to the TAMA_API_KEY environment variable, so the
+// commands can be run as they are once it is set.
+func (c *Client) ExportCurl(w io.Writer) error {
+	for _, capture := range c.Captures() {
+		if _, err := io.WriteString(w, capture.Curl()+"\n\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Curl returns the attempt as a curl command.
+func (c Capture) Curl() string {
+	var b strings.Builder
+	outcome := c.Status
+	if c.Err != "" {
+		outcome = "error: " + c.Err
+	}
+	fmt.Fprintf(&b, "# %s (attempt %d): %s\n", c.Operation, c.Attempt, outcome)
+	fmt.Fprintf(&b, "curl -X %s %s", c.Method, shellQuote(c.URL))
+
+	for _, name := range slices.Sorted(maps.Keys(c.RequestHeader)) {
+		if name == "Content-Length" || name == "Accept-Encoding" {
+			continue
+		}
+		for _, value := range c.RequestHeader[name] {
+			if name == "Authorization" {
+				// Double quotes let the shell expand the variable.
+				fmt.Fprintf(&b, " \\\n  -H \"Authorization: Bearer $%s\"", EnvAPIKey)
+				continue
+			}
+			fmt.Fprintf(&b, " \\\n  -H %s", shellQuote(name+": "+value))
+		}
+	}
+	if len(c.RequestBody) > 0 {
+		fmt.Fprintf(&b, " \\\n  --data-raw %s", shellQuote(string(c.RequestBody)))
+	}
+	return b.String()
+}
+
+// shellQuote quotes s for a POSIX shell.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// HAR 1.2 types, see http://www.softwareishard.com/blog/har-12-spec/.
+type (
+	harFile struct {
+		Log harLog `json:"log"`
+	}
+	harLog struct {
+		Version string     `json:"version"`
+		Creator harCreator `json:"creator"`
+		Entries []harEntry `json:"entries"`
+	}
+	harCreator struct {
+		Name    string `json:"name"`
+		Version string `json:"version"`
+	}
+	harEntry struct {
+		StartedDateTime string      `json:"startedDateTime"`
+		Time            float64     `json:"time"`
+		Request         harRequest  `json:"request"`
+		Response        harResponse `json:"response"`
+		Cache           struct{}    `json:"cache"`
+		Timings         harTimings  `json:"timings"`
+		Comment         string      `json:"comment,omitempty"`
+		Error           string      `json:"_error,omitempty"`
+	}
+	harRequest struct {
+		Method      string       `json:"method"`
+		URL         string       `json:"url"`
+		HTTPVersion string       `json:"httpVersion"`
+		Cookies     []struct{}   `json:"cookies"`
+		Headers     []harNameVal `json:"headers"`
+		QueryString []harNameVal `json:"queryString"`
+		PostData    *harPostData `json:"postData,omitempty"`
+		HeadersSize int          `json:"headersSize"`
+		BodySize    int          `json:"bodySize"`
+	}
+	harResponse struct {
+		Status      int          `json:"status"`
+		StatusText  string       `json:"statusText"`
+		HTTPVersion string       `json:"httpVersion"`
+		Cookies     []struct{}   `json:"cookies"`
+		Headers     []harNameVal `json:"headers"`
+		Content     harContent   `json:"content"`
+		RedirectURL string       `json:"redirectURL"`
+		HeadersSize int          `json:"headersSize"`
+		BodySize    int          `json:"bodySize"`
+	}
+	harNameVal struct {
+		Name  string `json:"name"`
+		Value string `json:"value"`
+	}
+	harPostData struct {
+		MimeType string `json:"mimeType"`
+		Text     string `json:"text"`
+	}
+	harContent struct {
+		Size     int    `json:"size"`
+		MimeType string `json:"mimeType"`
+		Text     string `json:"text,omitempty"`
+	}
+	harTimings struct {
+		Send    float64 `json:"send"`
+		Wait    float64 `json:"wait"`
+		Receive float64 `json:"receive"`
+	}
+)
+
+// ExportHAR writes the captured attempts as a HAR 1.2 file, which browsers'
+// developer tools and many HTTP debugging tools can import. Attempts that
+// failed without a response have status 0 and their error in "_error".
+func (c *Client) ExportHAR(w io.Writer) error {
+	captures := c.Captures()
+	har := harFile{Log: harLog{
+		Version: "1.2",
+		Creator: harCreator{Name: "tama-go", Version: "1"},
+		Entries: make([]harEntry, 0, len(captures)),
+	}}
+	for _, capture := range captures {
+		har.Log.Entries = append(har.Log.Entries, capture.harEntry())
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(har)
+}
+
+// harEntry converts the attempt to a HAR entry.
+func (c Capture) harEntry() harEntry {
+	ms := float64(c.Duration) / float64(time.Millisecond)
+	entry := harEntry{
+		StartedDateTime: c.Started.UTC().Format(time.RFC3339Nano),
+		Time:            ms,
+		Request: harRequest{
+			Method:      c.Method,
+			URL:         c.URL,
+			HTTPVersion: "HTTP/1.1",
+			Cookies:     []struct{}{},
+			Headers:     harHeaders(c.RequestHeader),
+			QueryString: []harNameVal{},
+			HeadersSize: -1,
+			BodySize:    len(c.RequestBody),
+		},
+		Response: harResponse{
+			Status:      c.StatusCode,
+			StatusText:  strings.TrimSpace(strings.TrimPrefix(c.Status, fmt.Sprint(c.StatusCode))),
+			HTTPVersion: "HTTP/1.1",
+			Cookies:     []struct{}{},
+			Headers:     harHeaders(c.ResponseHeader),
+			Content: harContent{
+				Size:     len(c.ResponseBody),
+				MimeType: c.ResponseHeader.Get("Content-Type"),
+				Text:     string(c.ResponseBody),
+			},
+			HeadersSize: -1,
+			BodySize:    len(c.ResponseBody),
+		},
+		Timings: harTimings{Wait: ms},
+		Comment: fmt.Sprintf("%s (attempt %d)", c.Operation, c.Attempt),
+		Error:   c.Err,
+	}
+	if len(c.RequestBody) > 0 {
+		entry.Request.PostData = &harPostData{
+			MimeType: c.RequestHeader.Get("Content-Type"),
+			Text:     string(c.RequestBody),
+		}
+	}
+	return entry
+}
+
+// harHeaders converts headers to HAR name/value pairs in a stable order.
+func harHeaders(header http.Header) []harNameVal {
+	pairs := []harNameVal{}
+	for _, name := range slices.Sorted(maps.Keys(header)) {
+		for _, value := range header[name] {
+			pairs = append(pairs, harNameVal{Name: name, Value: value})
+		}
+	}
+	return pairs
+}
@@ -0,0 +1,188 @@
+package tama_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	tama "github.com/upmaru/tama-go"
+	"github.com/upmaru/tama-go/memory"
+	"github.com/upmaru/tama-go/sensory"
+)
+
+func TestCaptureExports(t *testing.T) {
+	server := createMockServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Set-Cookie", "session=server-secret")
+		if r.Method == http.MethodPost {
+			w.WriteHeader(http.StatusCreated)
+		}
+		_, _ = w.Write([]byte(`{"data":{"id":"resource-123"}}`))
+	})
+	defer server.Close()
+
+	client := tama.NewClient(tama.Config{BaseURL: server.URL, APIKey: "client-secret"})
+	if _, err := client.Neural.GetSpace("space-before-capture"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	client.StartCapture(0)
+	if _, err := client.Sensory.CreateSource("space-123", sensory.CreateSourceRequest{
+		Source: sensory.SourceRequestData{
+			Name:       "Mistral",
+			Type:       "model",
+			Endpoint:   "https://api.mistral.ai/v1",
+			Credential: sensory.SourceCredential{APIKey: "source-secret"},
+		},
+	}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := client.Memory.CreatePrompt("space-123", memory.CreatePromptRequest{
+		Prompt: memory.PromptRequestData{Name: "Tone", Content: "Don't shout.", Role: "system"},
+	}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	captures := client.Captures()
+	if len(captures) != 2 {
+		t.Fatalf("Expected 2 captures, got %d", len(captures))
+	}
+	if captures[0].Operation != "sensory.CreateSource" || captures[0].StatusCode != http.StatusCreated {
+		t.Errorf("Unexpected capture: %+v", captures[0])
+	}
+
+	var curl bytes.Buffer
+	if err := client.ExportCurl(&curl); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var har bytes.Buffer
+	if err := client.ExportHAR(&har); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	for name, export := range map[string]string{"curl": curl.String(), "HAR": har.String()} {
+		for _, secret := range []string{"client-secret", "source-secret", "server-secret"} {
+			if strings.Contains(export, secret) {
+				t.Errorf("Expected %s export to redact %s:\n%s", name, secret, export)
+			}
+		}
+		if strings.Contains(export, "space-before-capture") {
+			t.Errorf("Expected %s export to omit calls made before capturing started", name)
+		}
+	}
+
+	for _, want := range []string{
+		"# sensory.CreateSource (attempt 1): 201 Created",
+		"curl -X POST '" + server.URL + "/provision/sensory/spaces/space-123/sources'",
+		`-H "Authorization: Bearer $TAMA_API_KEY"`,
+		`-H 'Content-Type: application/json'`,
+		`"content":"Don'\''t shout."`,
+	} {
+		if !strings.Contains(curl.String(), want) {
+			t.Errorf("Expected curl export to contain %s, got:\n%s", want, curl.String())
+		}
+	}
+
+	var file struct {
+		Log struct {
+			Version string `json:"version"`
+			Entries []struct {
+				Request struct {
+					Method   string `json:"method"`
+					PostData struct {
+						Text string `json:"text"`
+					} `json:"postData"`
+				} `json:"request"`
+				Response struct {
+					Status int `json:"status"`
+				} `json:"response"`
+			} `json:"entries"`
+		} `json:"log"`
+	}
+	if err := json.Unmarshal(har.Bytes(), &file); err != nil {
+		t.Fatalf("Invalid HAR: %v", err)
+	}
+	if file.Log.Version != "1.2" || len(file.Log.Entries) != 2 {
+		t.Fatalf("Unexpected HAR log: %+v", file.Log)
+	}
+	entry := file.Log.Entries[0]
+	if entry.Request.Method != http.MethodPost || entry.Response.Status != http.StatusCreated ||
+		!strings.Contains(entry.Request.PostData.Text, `"api_key":"[REDACTED]"`) {
+		t.Errorf("Unexpected HAR entry: %+v", entry)
+	}
+}
+
+func TestCaptureRingBuffer(t *testing.T) {
+	server := createMockServer(t, spaceHandler)
+	defer server.Close()
+
+	client := tama.NewClient(tama.Config{BaseURL: server.URL, APIKey: "test-key"})
+	get := func(id string) {
+		t.Helper()
+		if _, err := client.Neural.GetSpace(id); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	urls := func() []string {
+		var urls []string
+		for _, capture := range client.Captures() {
+			urls = append(urls, capture.URL[strings.LastIndex(capture.URL, "/")+1:])
+		}
+		return urls
+	}
+
+	client.StartCapture(3)
+	for i := range 5 {
+		get(fmt.Sprintf("space-%d", i))
+	}
+	if got := fmt.Sprint(urls()); got != "[space-2 space-3 space-4]" {
+		t.Errorf("Expected the 3 newest captures, got %s", got)
+	}
+
+	client.StopCapture()
+	get("space-5")
+	if got := fmt.Sprint(urls()); got != "[space-2 space-3 space-4]" {
+		t.Errorf("Expected no captures while stopped, got %s", got)
+	}
+
+	client.StartCapture(2)
+	if got := fmt.Sprint(urls()); got != "[space-3 space-4]" {
+		t.Errorf("Expected resizing to keep the newest captures, got %s", got)
+	}
+	get("space-6")
+	if got := fmt.Sprint(urls()); got != "[space-4 space-6]" {
+		t.Errorf("Expected the buffer to wrap, got %s", got)
+	}
+
+	client.ClearCaptures()
+	if len(client.Captures()) != 0 {
+		t.Error("Expected no captures after clearing")
+	}
+}
+
+func TestCaptureFailedAttempt(t *testing.T) {
+	server := createMockServer(t, spaceHandler)
+	server.Close()
+
+	client := tama.NewClient(tama.Config{BaseURL: server.URL, APIKey: "test-key"})
+	client.StartCapture(1)
+	if _, err := client.Neural.GetSpace("space-123"); err == nil {
+		t.Fatal("Expected error, got nil")
+	}
+
+	captures := client.Captures()
+	if len(captures) != 1 || captures[0].Err == "" || captures[0].StatusCode != 0 {
+		t.Fatalf("Expected a failed capture, got %+v", captures)
+	}
+
+	var har bytes.Buffer
+	if err := client.ExportHAR(&har); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !strings.Contains(har.String(), `"_error"`) {
+		t.Errorf("Expected the error in the HAR entry, got:\n%s", har.String())
+	}
+}
@@ -2,10 +2,12 @@ package tama
 
 import (
 	"fmt"
+	"log/slog"
+	"net/http"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
-
-	"github.com/go-resty/resty/v2"
 )
 
 const (
@@ -14,13 +16,44 @@ const (
 )
 
 // Client represents the main Tama API client.
+//
+// A Client is safe for concurrent use by multiple goroutines. All service
+// methods, BreakerState and CacheStats may be called concurrently, and so may
+// With and WithAPIKey. SetAPIKey, SetCredentials, SetHeader, SetDebug and Use
+// are safe to call while requests are in flight, but they change the client
+// for every goroutine using it; requests that already started keep the
+// previous settings. To give different callers different credentials or
+// headers, such as one per tenant, derive a client for each with With or
+// WithAPIKey instead of mutating a shared one.
+//
+// Call Close to shut a client down gracefully once it is no longer needed.
 type Client struct {
-	httpClient *resty.Client
-	baseURL    string
-	apiKey     string
-	Neural     *NeuralService
-	Sensory    *SensoryService
-	Memory     *MemoryService
+	config             Config
+	httpClient         *http.Client
+	baseURL            string
+	timeout            time.Duration
+	timeouts           *TimeoutPolicy
+	mu                 sync.RWMutex
+	credentialProvider CredentialProvider
+	headers            http.Header
+	debug              atomic.Bool
+	debugLog           *slog.Logger
+	log                *slog.Logger
+	logLevel           slog.Leveler
+	transport          http.RoundTripper
+	middleware         *middlewareTransport
+	breaker            *breakerTransport
+	cache              *cacheTransport
+	compression        *compressor
+	capabilities       *capabilityCache
+	captures           *captureBuffer
+	outbox             *Outbox
+	lifecycle          *lifecycle
+	tracer             Tracer
+	metrics            Metrics
+	Neural             *NeuralService
+	Sensory            *SensoryService
+	Memory             *MemoryService
 }
 
 // Config holds configuration options for the client.
@@ -28,28 +61,144 @@ type Config struct {
 	BaseURL string
 	APIKey  string
 	Timeout time.Duration
+	// Timeouts sets the timeouts of reads, writes and deletes, overall and
+	// per resource type. Calls it does not cover use Timeout.
+	Timeouts *TimeoutPolicy
+	// Credentials supplies the API key for every request. It takes precedence
+	// over APIKey; when nil, APIKey is used as a StaticCredential.
+	Credentials CredentialProvider
+	// Retry enables automatic retries of failed requests. Nil disables retries.
+	Retry *RetryPolicy
+	// RateLimit enables client-side request throttling. Rate-limit headers
+	// returned by the API are honoured either way.
+	RateLimit *RateLimit
+	// CircuitBreaker enables a circuit breaker per service. Nil disables it.
+	CircuitBreaker *CircuitBreakerConfig
+	// Cache enables an in-memory read-through cache for Get calls. Nil
+	// disables it.
+	Cache *CacheConfig
+	// Compression enables gzip compression of large request bodies and
+	// decoding of compressed responses. Nil disables both.
+	Compression *CompressionConfig
+	// Outbox, if set, stores mutations that fail because the API is
+	// unreachable so they can be replayed with ReplayOutbox. Such calls
+	// return a *QueuedError.
+	Outbox *Outbox
+	// Audit, if set, records every create, update, replace and delete call.
+	Audit *AuditConfig
+	// Tracer, if set, starts a span for every API call.
+	Tracer Tracer
+	// Metrics receives measurements for every API call. Nil means
+	// DefaultMetrics, which are published with expvar.
+	Metrics Metrics
+	// Logger receives structured records of every call, with the operation,
+	// path, status and duration. Authorization headers and credential fields
+	// are always redacted. Nil disables logging.
+	Logger *slog.Logger
+	// LogLevel is the minimum level of the records the client writes to
+	// Logger. Nil means slog.LevelInfo: failed calls are logged, successful
+	// calls and individual attempts are not.
+	LogLevel slog.Leveler
+	// APIVersion, if set, is sent as the Tama-Api-Version header of every
+	// request to ask the server for the behaviour of that API version. The
+	// client then also probes the server's capabilities before its first
+	// call, so that calls the server does not support fail early with an
+	// *UnsupportedError. Without it the capabilities are only checked once
+	// Client.Capabilities has been called.
+	APIVersion string
+	// UserAgent, if set, is sent as the User-Agent header of every request.
+	UserAgent string
+	// Headers are sent with every request, in addition to those set with
+	// SetHeader.
+	Headers http.Header
+	// TLS customises root CAs, client certificates, the minimum TLS version
+	// and public key pinning. It replaces the TLS settings of HTTPClient's
+	// transport, which must then be nil or an *http.Transport. If the
+	// settings cannot be applied, every request fails; NewClientWithOptions
+	// reports the problem up front instead.
+	TLS *TLSConfig
+	// HTTPClient sends the requests. Nil means a new client that uses
+	// http.DefaultTransport. Timeout is applied per call on top of any timeout
+	// already set on the client.
+	HTTPClient *http.Client
 }
 
-// NewClient creates a new Tama API client.
+// NewClient creates a new Tama API client. It does not validate config; use
+// NewClientWithOptions to have invalid settings reported up front.
 func NewClient(config Config) *Client {
+	httpClient, err := configHTTPClient(config)
+	if err != nil {
+		httpClient = &http.Client{Transport: failingTransport{err: err}}
+	}
+	return newClient(config, httpClient)
+}
+
+// newClient creates a client from config that sends requests with httpClient.
+func newClient(config Config, httpClient *http.Client) *Client {
 	if config.Timeout == 0 {
 		config.Timeout = DefaultTimeout
 	}
 
-	httpClient := resty.New().
-		SetBaseURL(config.BaseURL).
-		SetTimeout(config.Timeout).
-		SetHeader("Content-Type", "application/json").
-		SetHeader("Accept", "application/json")
+	metrics := config.Metrics
+	if metrics == nil {
+		metrics = DefaultMetrics()
+	}
 
-	if config.APIKey != "" {
-		httpClient.SetAuthToken(config.APIKey)
+	credentials := config.Credentials
+	if credentials == nil && config.APIKey != "" {
+		credentials = StaticCredential(config.APIKey)
 	}
 
 	client := &Client{
-		httpClient: httpClient,
-		baseURL:    config.BaseURL,
-		apiKey:     config.APIKey,
+		config:             config,
+		httpClient:         httpClient,
+		baseURL:            config.BaseURL,
+		timeout:            config.Timeout,
+		credentialProvider: credentials,
+		headers:            config.Headers.Clone(),
+		tracer:             config.Tracer,
+		metrics:            metrics,
+		log:                config.Logger,
+		debugLog:           newDebugLogger(),
+		logLevel:           config.LogLevel,
+		capabilities:       newCapabilityCache(),
+		captures:           &captureBuffer{},
+		outbox:             config.Outbox,
+		lifecycle:          newLifecycle(nil),
+	}
+	if config.Compression != nil {
+		client.compression = newCompressor(config.Compression)
+	}
+	if config.Timeouts != nil {
+		client.timeouts = config.Timeouts.clone()
+	}
+	if config.Audit != nil {
+		audit := *config.Audit
+		client.config.Audit = &audit
+	}
+
+	if client.headers == nil {
+		client.headers = make(http.Header)
+	}
+	if config.UserAgent != "" {
+		client.headers.Set("User-Agent", config.UserAgent)
+	}
+	if config.APIVersion != "" {
+		client.headers.Set(APIVersionHeader, config.APIVersion)
+	}
+
+	client.middleware = newMiddlewareTransport(sendFunc(client.send), config.BaseURL)
+	client.transport = newThrottleTransport(client.middleware, config.RateLimit)
+	if config.Retry != nil {
+		client.transport = newRetryTransport(client.transport, *config.Retry)
+	}
+	if config.CircuitBreaker != nil {
+		client.breaker = newBreakerTransport(client.transport, *config.CircuitBreaker)
+		client.transport = client.breaker
+	}
+	if config.Cache != nil {
+		client.cache = newCacheTransport(client.transport, *config.Cache)
+		client.transport = client.cache
 	}
 
 	// Initialize services
@@ -60,20 +209,34 @@ func NewClient(config Config) *Client {
 	return client
 }
 
-// SetAPIKey sets the API key for authentication.
+// SetAPIKey sets the API key for authentication, replacing any configured
+// credential provider. It is safe to call while requests are in flight;
+// requests that have already been authorized keep the previous key.
 func (c *Client) SetAPIKey(apiKey string) {
-	c.apiKey = apiKey
-	c.httpClient.SetAuthToken(apiKey)
+	c.SetCredentials(StaticCredential(apiKey))
 }
 
-// SetDebug enables or disables debug mode for HTTP requests.
+// SetCredentials replaces the credential provider used for new requests.
+func (c *Client) SetCredentials(provider CredentialProvider) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.credentialProvider = provider
+}
+
+// SetDebug enables or disables debug mode for HTTP requests. In debug mode
+// every attempt is logged at debug level with its redacted headers and body,
+// to Config.Logger if set and to standard error otherwise.
 func (c *Client) SetDebug(debug bool) {
-	c.httpClient.SetDebug(debug)
+	c.debug.Store(debug)
 }
 
-// SetHeader sets a header on the HTTP client.
+// SetHeader sets a header that is sent with every request.
 func (c *Client) SetHeader(header, value string) {
-	c.httpClient.SetHeader(header, value)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.headers.Set(header, value)
 }
 
 // Error represents an API error response.
@@ -1,6 +1,7 @@
 package tama_test
 
 import (
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -75,7 +76,96 @@ func TestSetDebug(_ *testing.T) {
 
 	client.SetDebug(true)
 	// Note: In a real implementation, you might want to verify that debug mode is actually set
-	// This would depend on how debug mode is implemented in the resty client
+	// This would depend on where the debug output is written
+}
+
+// roundTripFunc lets a function be used as an http.RoundTripper in tests.
+type roundTripFunc func(r *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+func TestNewClientWithHTTPClient(t *testing.T) {
+	var seen *http.Request
+	httpClient := &http.Client{
+		Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+			seen = r
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Header:     http.Header{"Content-Type": {"application/json"}},
+				Body:       io.NopCloser(strings.NewReader(`{"data": {"id": "space-123", "name": "injected"}}`)),
+				Request:    r,
+			}, nil
+		}),
+	}
+
+	client := tama.NewClient(tama.Config{
+		BaseURL:    "https://api.example.com",
+		APIKey:     "test-key",
+		HTTPClient: httpClient,
+	})
+
+	space, err := client.Neural.GetSpace("space-123")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if space.Name != "injected" {
+		t.Errorf("Expected space name injected, got %s", space.Name)
+	}
+
+	if seen == nil {
+		t.Fatal("Expected request to go through the injected transport")
+	}
+
+	if seen.URL.String() != "https://api.example.com/provision/neural/spaces/space-123" {
+		t.Errorf("Expected request URL to be resolved against base URL, got %s", seen.URL)
+	}
+
+	if seen.Header.Get("Authorization") != "Bearer test-key" {
+		t.Errorf("Expected bearer token, got %q", seen.Header.Get("Authorization"))
+	}
+}
+
+func TestSetHeader(t *testing.T) {
+	server := createMockServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Custom") != "custom-value" {
+			t.Errorf("Expected X-Custom header, got %q", r.Header.Get("X-Custom"))
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+	defer server.Close()
+
+	client := tama.NewClient(tama.Config{BaseURL: server.URL, APIKey: "test-key"})
+	client.SetHeader("X-Custom", "custom-value")
+
+	if err := client.Neural.DeleteSpace("space-123"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+}
+
+func TestServiceWithPlainHTTPClient(t *testing.T) {
+	server := createMockServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/provision/neural/spaces/space-123" {
+			t.Errorf("Expected path /provision/neural/spaces/space-123, got %s", r.URL.Path)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"data": {"id": "space-123"}}`))
+	})
+	defer server.Close()
+
+	service := neural.NewService(server.Client(), server.URL+"/")
+
+	space, err := service.GetSpace("space-123")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if space.ID != "space-123" {
+		t.Errorf("Expected space ID space-123, got %s", space.ID)
+	}
 }
 
 func TestErrorStruct(t *testing.T) {
@@ -0,0 +1,134 @@
+package tama
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrClientClosed is returned by calls made after Close, and by calls that
+// Close cancelled because they were still in flight when its grace period ran
+// out.
+var ErrClientClosed = errors.New("client closed")
+
+// callAbortedError is the cancellation cause of calls cancelled by Close. It
+// matches both ErrClientClosed and context.Canceled.
+type callAbortedError struct{}
+
+func (callAbortedError) Error() string {
+	return ErrClientClosed.Error() + " before the call completed: " + context.Canceled.Error()
+}
+
+func (callAbortedError) Unwrap() []error {
+	return []error{ErrClientClosed, context.Canceled}
+}
+
+// lifecycle tracks the calls in flight on a client so that Close can drain
+// them. The lifecycle of a derived client points to that of the client it was
+// derived from, so closing a client closes the clients derived from it too,
+// without the parent having to keep track of them.
+type lifecycle struct {
+	parent   *lifecycle
+	mu       sync.Mutex
+	closed   bool
+	inFlight sync.WaitGroup
+	// abort is cancelled when calls still in flight must stop.
+	abort  context.Context
+	cancel context.CancelCauseFunc
+}
+
+func newLifecycle(parent *lifecycle) *lifecycle {
+	abort, cancel := context.WithCancelCause(context.Background())
+	return &lifecycle{parent: parent, abort: abort, cancel: cancel}
+}
+
+// start registers a call made with ctx. It returns ErrClientClosed if the
+// client or one it was derived from is closed. Otherwise it returns a copy of
+// ctx that Close cancels, and a function that must be called when the call
+// ends.
+func (l *lifecycle) start(ctx context.Context) (context.Context, func(), error) {
+	var joined []*lifecycle
+	end := func() {
+		for _, j := range joined {
+			j.inFlight.Done()
+		}
+	}
+
+	for cur := l; cur != nil; cur = cur.parent {
+		cur.mu.Lock()
+		if cur.closed {
+			cur.mu.Unlock()
+			end()
+			return nil, nil, ErrClientClosed
+		}
+		cur.inFlight.Add(1)
+		cur.mu.Unlock()
+		joined = append(joined, cur)
+	}
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	stops := make([]func() bool, 0, len(joined))
+	for _, j := range joined {
+		stops = append(stops, context.AfterFunc(j.abort, func() { cancel(callAbortedError{}) }))
+	}
+	return ctx, func() {
+		for _, stop := range stops {
+			stop()
+		}
+		cancel(context.Canceled)
+		end()
+	}, nil
+}
+
+// close stops new calls and waits for those in flight until ctx is done, then
+// cancels the rest and waits for them to return.
+func (l *lifecycle) close(ctx context.Context) error {
+	l.mu.Lock()
+	l.closed = true
+	l.mu.Unlock()
+
+	drained := make(chan struct{})
+	go func() {
+		l.inFlight.Wait()
+		close(drained)
+	}()
+
+	select {
+	case <-drained:
+		return nil
+	case <-ctx.Done():
+		l.cancel(callAbortedError{})
+		<-drained
+		return fmt.Errorf("cancelled calls still in flight: %w", ctx.Err())
+	}
+}
+
+// Close shuts the client down gracefully. Calls made after Close starts fail
+// with ErrClientClosed. Calls in flight may finish until ctx is done; those
+// still running then are cancelled and fail with an error that matches
+// ErrClientClosed, and Close returns an error wrapping ctx.Err(). Once no call
+// is in flight, the client's idle connections are closed and its cache is
+// emptied.
+//
+// Closing a client also closes the clients derived from it with With or
+// WithAPIKey. Closing a derived client does not affect the client it was
+// derived from, although both lose their idle connections if they share a
+// connection pool. Close does not close Config.Outbox or the audit sink,
+// which belong to the caller. Calling Close again waits for calls in flight
+// the same way.
+//
+//	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+//	defer cancel()
+//	if err := client.Close(ctx); err != nil {
+//		log.Printf("tama: %v", err)
+//	}
+func (c *Client) Close(ctx context.Context) error {
+	err := c.lifecycle.close(ctx)
+
+	c.httpClient.CloseIdleConnections()
+	if c.cache != nil {
+		c.cache.purge()
+	}
+	return err
+}
@@ -0,0 +1,148 @@
+package tama_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	tama "github.com/upmaru/tama-go"
+)
+
+// idleTracker is a transport that counts calls to CloseIdleConnections.
+type idleTracker struct {
+	http.RoundTripper
+	closed atomic.Int32
+}
+
+func (t *idleTracker) CloseIdleConnections() {
+	t.closed.Add(1)
+}
+
+// slowServer answers requests for "slow-space" once release is closed or the
+// request is cancelled, and every other request at once.
+func slowServer(t *testing.T, received chan<- struct{}, release <-chan struct{}) http.HandlerFunc {
+	t.Helper()
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/provision/neural/spaces/slow-space" {
+			received <- struct{}{}
+			select {
+			case <-release:
+			case <-r.Context().Done():
+				return
+			}
+		}
+		spaceHandler(w, r)
+	}
+}
+
+func TestCloseDrainsCalls(t *testing.T) {
+	received, release := make(chan struct{}, 1), make(chan struct{})
+	server := createMockServer(t, slowServer(t, received, release))
+	defer server.Close()
+
+	tracker := &idleTracker{RoundTripper: http.DefaultTransport}
+	client := tama.NewClient(tama.Config{
+		BaseURL:    server.URL,
+		APIKey:     "test-key",
+		HTTPClient: &http.Client{Transport: tracker},
+	})
+
+	inFlight := make(chan error, 1)
+	go func() {
+		_, err := client.Neural.GetSpace("slow-space")
+		inFlight <- err
+	}()
+	<-received
+
+	closed := make(chan error, 1)
+	go func() { closed <- client.Close(context.Background()) }()
+
+	// New calls are rejected as soon as Close starts.
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		_, err := client.Neural.GetSpace("space-123")
+		if errors.Is(err, tama.ErrClientClosed) {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected ErrClientClosed, got %v", err)
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	select {
+	case err := <-closed:
+		t.Fatalf("Expected Close to wait for the call in flight, got %v", err)
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	close(release)
+	if err := <-inFlight; err != nil {
+		t.Errorf("Expected the call in flight to finish, got %v", err)
+	}
+	if err := <-closed; err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if tracker.closed.Load() == 0 {
+		t.Error("Expected idle connections to be closed")
+	}
+}
+
+func TestCloseCancelsAfterGracePeriod(t *testing.T) {
+	received, release := make(chan struct{}, 1), make(chan struct{})
+	server := createMockServer(t, slowServer(t, received, release))
+	defer server.Close()
+	defer close(release)
+
+	client := tama.NewClient(tama.Config{BaseURL: server.URL, APIKey: "test-key"})
+
+	inFlight := make(chan error, 1)
+	go func() {
+		_, err := client.Neural.GetSpace("slow-space")
+		inFlight <- err
+	}()
+	<-received
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := client.Close(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected Close to report the expired grace period, got %v", err)
+	}
+
+	err := <-inFlight
+	if !errors.Is(err, tama.ErrClientClosed) || !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected the call to be cancelled with ErrClientClosed, got %v", err)
+	}
+}
+
+func TestCloseDerivedClients(t *testing.T) {
+	server := createMockServer(t, spaceHandler)
+	defer server.Close()
+
+	client := tama.NewClient(tama.Config{BaseURL: server.URL, APIKey: "test-key"})
+	tenant := client.WithAPIKey("tenant-key")
+	other := client.WithAPIKey("other-key")
+
+	if err := tenant.Close(context.Background()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := tenant.Neural.GetSpace("space-123"); !errors.Is(err, tama.ErrClientClosed) {
+		t.Errorf("Expected ErrClientClosed, got %v", err)
+	}
+	if _, err := client.Neural.GetSpace("space-123"); err != nil {
+		t.Errorf("Expected closing a derived client to leave its parent open, got %v", err)
+	}
+	if _, err := other.Neural.GetSpace("space-123"); err != nil {
+		t.Errorf("Expected closing a derived client to leave its siblings open, got %v", err)
+	}
+
+	if err := client.Close(context.Background()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := other.Neural.GetSpace("space-123"); !errors.Is(err, tama.ErrClientClosed) {
+		t.Errorf("Expected closing a client to close the clients derived from it, got %v", err)
+	}
+}
@@ -0,0 +1,169 @@
+package tama
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// DefaultCompressionThreshold is the smallest request body, in bytes, that is
+// compressed when no threshold is configured. Smaller bodies rarely shrink
+// enough to be worth the effort.
+const DefaultCompressionThreshold = 1024
+
+// MaxDecodedResponseSize is the largest response body, in bytes, that is
+// accepted after decoding a gzip or deflate encoded response. Larger bodies
+// fail the call, so that a small compressed response cannot exhaust memory.
+const MaxDecodedResponseSize = 32 << 20
+
+// CompressionConfig enables compression of request and response bodies.
+type CompressionConfig struct {
+	// Requests enables gzip compression of request bodies of at least
+	// RequestThreshold bytes. The API must accept Content-Encoding: gzip.
+	Requests bool
+	// RequestThreshold is the smallest request body that is compressed. Zero
+	// means DefaultCompressionThreshold.
+	RequestThreshold int
+	// Level is the gzip compression level, from gzip.BestSpeed to
+	// gzip.BestCompression. Zero means gzip.DefaultCompression.
+	Level int
+	// Responses asks the API for gzip or deflate encoded responses and
+	// decodes them before they reach the services.
+	Responses bool
+}
+
+// threshold returns the smallest request body that is compressed.
+func (c *CompressionConfig) threshold() int {
+	if c.RequestThreshold <= 0 {
+		return DefaultCompressionThreshold
+	}
+	return c.RequestThreshold
+}
+
+// level returns the gzip compression level.
+func (c *CompressionConfig) level() int {
+	if c.Level == 0 {
+		return gzip.DefaultCompression
+	}
+	return c.Level
+}
+
+// validate checks that the compression level is supported.
+func (c *CompressionConfig) validate() error {
+	if _, err := gzip.NewWriterLevel(io.Discard, c.level()); err != nil {
+		return err
+	}
+	return nil
+}
+
+// compressor applies a CompressionConfig. It pools gzip writers, as each one
+// holds several hundred kilobytes of state.
+type compressor struct {
+	config  CompressionConfig
+	writers sync.Pool
+}
+
+// newCompressor returns a compressor for a copy of config.
+func newCompressor(config *CompressionConfig) *compressor {
+	return &compressor{config: *config}
+}
+
+// compressRequest returns a copy of req with its body gzip-compressed if it
+// is large enough, and with Accept-Encoding set if responses are decoded.
+func (c *compressor) compressRequest(req *http.Request) (*http.Request, error) {
+	if c.config.Responses && req.Header.Get("Accept-Encoding") == "" {
+		req = req.Clone(req.Context())
+		req.Header.Set("Accept-Encoding", "gzip, deflate")
+	}
+	if !c.config.Requests || req.Header.Get("Content-Encoding") != "" {
+		return req, nil
+	}
+
+	data, err := readBody(req)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < c.config.threshold() {
+		return req, nil
+	}
+
+	var buf bytes.Buffer
+	writer, err := c.writer(&buf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compress request body: %w", err)
+	}
+	defer c.writers.Put(writer)
+	if _, err := writer.Write(data); err != nil {
+		return nil, fmt.Errorf("failed to compress request body: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return nil, fmt.Errorf("failed to compress request body: %w", err)
+	}
+
+	req = req.Clone(req.Context())
+	req.Header.Set("Content-Encoding", "gzip")
+	setBody(req, buf.Bytes())
+	return req, nil
+}
+
+// writer returns a pooled gzip writer that writes to w.
+func (c *compressor) writer(w io.Writer) (*gzip.Writer, error) {
+	if writer, ok := c.writers.Get().(*gzip.Writer); ok {
+		writer.Reset(w)
+		return writer, nil
+	}
+	return gzip.NewWriterLevel(w, c.config.level())
+}
+
+// decodeResponse decodes a gzip or deflate encoded response body and removes
+// the Content-Encoding header. Other encodings are returned unchanged.
+func decodeResponse(resp *http.Response, body []byte) ([]byte, error) {
+	encoding := strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Encoding")))
+	if encoding != "gzip" && encoding != "deflate" {
+		return body, nil
+	}
+
+	decoded, err := decodeBody(encoding, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s response body: %w", encoding, err)
+	}
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = int64(len(decoded))
+	resp.Uncompressed = true
+	return decoded, nil
+}
+
+// decodeBody decompresses body. Deflate bodies are expected in the zlib format
+// required by HTTP, but raw deflate streams sent by some servers are accepted
+// too.
+func decodeBody(encoding string, body []byte) ([]byte, error) {
+	var reader io.ReadCloser
+	if encoding == "gzip" {
+		gz, err := gzip.NewReader(bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		reader = gz
+	} else if zr, err := zlib.NewReader(bytes.NewReader(body)); err == nil {
+		reader = zr
+	} else {
+		reader = flate.NewReader(bytes.NewReader(body))
+	}
+	defer reader.Close()
+
+	decoded, err := io.ReadAll(io.LimitReader(reader, MaxDecodedResponseSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(decoded) > MaxDecodedResponseSize {
+		return nil, fmt.Errorf("decoded body exceeds %d bytes", MaxDecodedResponseSize)
+	}
+	return decoded, nil
+}
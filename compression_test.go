@@ -0,0 +1,256 @@
+package tama_test
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	tama "github.com/upmaru/tama-go"
+	"github.com/upmaru/tama-go/memory"
+	"github.com/upmaru/tama-go/sensory"
+)
+
+// promptContent is a system prompt of typical length.
+var promptContent = strings.Repeat(
+	"You are a support assistant for an online store. Answer questions about orders, shipping and returns "+
+		"politely and concisely. When you do not know the answer, say so and offer to connect the customer "+
+		"with a human agent. Never reveal internal notes or other customers' data.\n", 12)
+
+// modelParameters are the parameters of a model with a typical tool schema.
+func modelParameters() map[string]any {
+	properties := map[string]any{}
+	for i := range 20 {
+		properties[fmt.Sprintf("field_%02d", i)] = map[string]any{
+			"type":        "string",
+			"description": fmt.Sprintf("The value of field %d, as shown on the order confirmation page.", i),
+		}
+	}
+	return map[string]any{
+		"temperature": 0.2,
+		"max_tokens":  2048,
+		"tools": []any{map[string]any{
+			"type": "function",
+			"function": map[string]any{
+				"name":        "lookup_order",
+				"description": "Look up an order by the fields the customer provided.",
+				"parameters":  map[string]any{"type": "object", "properties": properties},
+			},
+		}},
+	}
+}
+
+// decompressRequest returns the request body, decompressing it if needed.
+func decompressRequest(t *testing.T, r *http.Request) []byte {
+	t.Helper()
+	var reader io.Reader = r.Body
+	if r.Header.Get("Content-Encoding") == "gzip" {
+		gz, err := gzip.NewReader(r.Body)
+		if err != nil {
+			t.Fatalf("Invalid gzip body: %v", err)
+		}
+		reader = gz
+	}
+	body, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return body
+}
+
+func TestCompressionRequests(t *testing.T) {
+	var encoding string
+	var request memory.CreatePromptRequest
+	server := createMockServer(t, func(w http.ResponseWriter, r *http.Request) {
+		encoding = r.Header.Get("Content-Encoding")
+		if err := json.Unmarshal(decompressRequest(t, r), &request); err != nil {
+			t.Errorf("Invalid request body: %v", err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"data":{"id":"prompt-123"}}`))
+	})
+	defer server.Close()
+
+	client := tama.NewClient(tama.Config{
+		BaseURL:     server.URL,
+		APIKey:      "test-key",
+		Compression: &tama.CompressionConfig{Requests: true},
+	})
+
+	tests := []struct {
+		name     string
+		content  string
+		encoding string
+	}{
+		{name: "above threshold", content: promptContent, encoding: "gzip"},
+		{name: "below threshold", content: "Be brief.", encoding: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := client.Memory.CreatePrompt("space-123", memory.CreatePromptRequest{
+				Prompt: memory.PromptRequestData{Name: "Support", Content: tt.content, Role: "system"},
+			})
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if encoding != tt.encoding {
+				t.Errorf("Expected Content-Encoding %q, got %q", tt.encoding, encoding)
+			}
+			if request.Prompt.Content != tt.content {
+				t.Error("Expected the server to receive the original content")
+			}
+		})
+	}
+}
+
+func TestCompressionResponses(t *testing.T) {
+	payload := []byte(`{"data":{"id":"space-123","name":"` + strings.Repeat("x", 2048) + `"}}`)
+	encoders := map[string]func(w io.Writer) io.WriteCloser{
+		"gzip": func(w io.Writer) io.WriteCloser { return gzip.NewWriter(w) },
+		"deflate": func(w io.Writer) io.WriteCloser {
+			return zlib.NewWriter(w)
+		},
+		"raw deflate": func(w io.Writer) io.WriteCloser {
+			fw, _ := flate.NewWriter(w, flate.DefaultCompression)
+			return fw
+		},
+	}
+
+	for name, encode := range encoders {
+		t.Run(name, func(t *testing.T) {
+			var acceptEncoding string
+			server := createMockServer(t, func(w http.ResponseWriter, r *http.Request) {
+				acceptEncoding = r.Header.Get("Accept-Encoding")
+				var buf bytes.Buffer
+				encoder := encode(&buf)
+				_, _ = encoder.Write(payload)
+				_ = encoder.Close()
+
+				w.Header().Set("Content-Type", "application/json")
+				w.Header().Set("Content-Encoding", strings.TrimPrefix(name, "raw "))
+				_, _ = w.Write(buf.Bytes())
+			})
+			defer server.Close()
+
+			client := tama.NewClient(tama.Config{
+				BaseURL:     server.URL,
+				APIKey:      "test-key",
+				Compression: &tama.CompressionConfig{Responses: true},
+			})
+
+			space, err := client.Neural.GetSpace("space-123")
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if space.ID != "space-123" || len(space.Name) != 2048 {
+				t.Errorf("Unexpected space: %s", space.ID)
+			}
+			if acceptEncoding != "gzip, deflate" {
+				t.Errorf("Expected Accept-Encoding gzip, deflate, got %q", acceptEncoding)
+			}
+		})
+	}
+}
+
+func TestCompressionResponseLimit(t *testing.T) {
+	var body bytes.Buffer
+	encoder := gzip.NewWriter(&body)
+	_, _ = encoder.Write(bytes.Repeat([]byte(" "), tama.MaxDecodedResponseSize+1))
+	_ = encoder.Close()
+
+	server := createMockServer(t, func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Encoding", "gzip")
+		_, _ = w.Write(body.Bytes())
+	})
+	defer server.Close()
+
+	client := tama.NewClient(tama.Config{
+		BaseURL:     server.URL,
+		APIKey:      "test-key",
+		Compression: &tama.CompressionConfig{Responses: true},
+	})
+
+	_, err := client.Neural.GetSpace("space-123")
+	if err == nil || !strings.Contains(err.Error(), "exceeds") {
+		t.Fatalf("Expected the oversized body to be rejected, got %v", err)
+	}
+}
+
+func TestCompressionInvalidLevel(t *testing.T) {
+	_, err := tama.NewClientWithOptions(
+		tama.WithBaseURL("https://api.tama.io"),
+		tama.WithCompression(tama.CompressionConfig{Requests: true, Level: 42}),
+	)
+	var configErr *tama.ConfigError
+	if !errors.As(err, &configErr) || configErr.Source != "Compression" {
+		t.Errorf("Expected Compression ConfigError, got %v", err)
+	}
+}
+
+// benchmarkCompression runs create calls with and without request
+// compression and reports the request body bytes sent per call.
+func benchmarkCompression(b *testing.B, create func(client *tama.Client) error) {
+	for _, compress := range []bool{false, true} {
+		name := "identity"
+		if compress {
+			name = "gzip"
+		}
+		b.Run(name, func(b *testing.B) {
+			var sent atomic.Int64
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n, _ := io.Copy(io.Discard, r.Body)
+				sent.Add(n)
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusCreated)
+				_, _ = w.Write([]byte(`{"data":{"id":"resource-123"}}`))
+			}))
+			defer server.Close()
+
+			client := tama.NewClient(tama.Config{
+				BaseURL:     server.URL,
+				APIKey:      "test-key",
+				Compression: &tama.CompressionConfig{Requests: compress},
+			})
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for range b.N {
+				if err := create(client); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.ReportMetric(float64(sent.Load())/float64(b.N), "sent-B/op")
+		})
+	}
+}
+
+func BenchmarkCompressionCreatePrompt(b *testing.B) {
+	req := memory.CreatePromptRequest{
+		Prompt: memory.PromptRequestData{Name: "Support", Content: promptContent, Role: "system"},
+	}
+	benchmarkCompression(b, func(client *tama.Client) error {
+		_, err := client.Memory.CreatePrompt("space-123", req)
+		return err
+	})
+}
+
+func BenchmarkCompressionCreateModel(b *testing.B) {
+	req := sensory.CreateModelRequest{
+		Model: sensory.ModelRequestData{Identifier: "gpt-4o", Path: "/chat/completions", Parameters: modelParameters()},
+	}
+	benchmarkCompression(b, func(client *tama.Client) error {
+		_, err := client.Sensory.CreateModel("source-123", req)
+		return err
+	})
+}
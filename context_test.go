@@ -0,0 +1,99 @@
+package tama_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	tama "github.com/upmaru/tama-go"
+	"github.com/upmaru/tama-go/memory"
+	"github.com/upmaru/tama-go/neural"
+	"github.com/upmaru/tama-go/sensory"
+)
+
+func TestGetSpaceWithContext(t *testing.T) {
+	server := createMockServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/provision/neural/spaces/space-123" {
+			t.Errorf("Expected path /provision/neural/spaces/space-123, got %s", r.URL.Path)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(neural.SpaceResponse{Data: neural.Space{ID: "space-123", Name: "ctx-space"}})
+	})
+	defer server.Close()
+
+	client := tama.NewClient(tama.Config{BaseURL: server.URL, APIKey: "test-key"})
+
+	space, err := client.Neural.GetSpaceWithContext(context.Background(), "space-123")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if space.Name != "ctx-space" {
+		t.Errorf("Expected space name ctx-space, got %s", space.Name)
+	}
+}
+
+func TestContextCancellation(t *testing.T) {
+	release := make(chan struct{})
+	server := createMockServer(t, func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+	defer server.Close()
+	defer close(release)
+
+	client := tama.NewClient(tama.Config{BaseURL: server.URL, APIKey: "test-key"})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		cancel()
+	}()
+
+	_, err := client.Sensory.CreateSourceWithContext(ctx, "space-123", sensory.CreateSourceRequest{
+		Source: sensory.SourceRequestData{
+			Name:     "Cancelled Source",
+			Type:     "model",
+			Endpoint: "https://api.example.com/v1",
+		},
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected context.Canceled, got %v", err)
+	}
+}
+
+func TestContextDeadline(t *testing.T) {
+	release := make(chan struct{})
+	server := createMockServer(t, func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+	defer server.Close()
+	defer close(release)
+
+	client := tama.NewClient(tama.Config{BaseURL: server.URL, APIKey: "test-key"})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	_, err := client.Memory.CreatePromptWithContext(ctx, "space-123", memory.CreatePromptRequest{
+		Prompt: memory.PromptRequestData{
+			Name:    "Deadline Prompt",
+			Content: "You are a helpful assistant.",
+			Role:    "system",
+		},
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected context.DeadlineExceeded, got %v", err)
+	}
+}
@@ -0,0 +1,227 @@
+package tama
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultRefreshLeeway is how long before expiry a RefreshingCredential renews its token.
+	DefaultRefreshLeeway = time.Minute
+)
+
+// ErrNoCredential is returned when a credential provider has no credential to offer.
+var ErrNoCredential = errors.New("no credential available")
+
+// CredentialProvider supplies the API key used to authenticate requests.
+// It is consulted once per request and must be safe for concurrent use.
+type CredentialProvider interface {
+	Credential(ctx context.Context) (string, error)
+}
+
+// CredentialInvalidator is implemented by providers that can discard a
+// credential the server rejected. When a request comes back 401 and the
+// provider implements this interface, the client invalidates the credential
+// and retries the request once if the provider then returns a different one.
+type CredentialInvalidator interface {
+	Invalidate(credential string)
+}
+
+// StaticCredential is a CredentialProvider that always returns the same key.
+type StaticCredential string
+
+// Credential implements CredentialProvider.
+func (c StaticCredential) Credential(_ context.Context) (string, error) {
+	return string(c), nil
+}
+
+// EnvCredential is a CredentialProvider that reads the key from the named
+// environment variable on every request.
+type EnvCredential string
+
+// Credential implements CredentialProvider.
+func (c EnvCredential) Credential(_ context.Context) (string, error) {
+	key := strings.TrimSpace(os.Getenv(string(c)))
+	if key == "" {
+		return "", fmt.Errorf("%w: environment variable %s is not set", ErrNoCredential, string(c))
+	}
+	return key, nil
+}
+
+// FileCredential is a CredentialProvider that reads the key from a file. The
+// file is read again whenever its size or modification time changes, so keys
+// can be rotated by replacing the file.
+type FileCredential struct {
+	path    string
+	mu      sync.Mutex
+	key     string
+	size    int64
+	modTime time.Time
+}
+
+// NewFileCredential creates a provider that reads the key from path.
+func NewFileCredential(path string) *FileCredential {
+	return &FileCredential{path: path}
+}
+
+// Credential implements CredentialProvider.
+func (c *FileCredential) Credential(_ context.Context) (string, error) {
+	info, err := os.Stat(c.path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read credential file: %w", err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.key != "" && info.Size() == c.size && info.ModTime().Equal(c.modTime) {
+		return c.key, nil
+	}
+
+	data, err := os.ReadFile(c.path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read credential file: %w", err)
+	}
+
+	key := string(bytes.TrimSpace(data))
+	if key == "" {
+		return "", fmt.Errorf("%w: credential file %s is empty", ErrNoCredential, c.path)
+	}
+
+	c.key, c.size, c.modTime = key, info.Size(), info.ModTime()
+	return c.key, nil
+}
+
+// Invalidate implements CredentialInvalidator by forcing the file to be read again.
+func (c *FileCredential) Invalidate(credential string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.key == credential {
+		c.key = ""
+	}
+}
+
+// TokenFunc fetches a new token and reports when it expires. A zero expiry
+// means the token does not expire.
+type TokenFunc func(ctx context.Context) (token string, expiry time.Time, err error)
+
+// RefreshingCredential is a CredentialProvider that caches a token obtained
+// from a TokenFunc and renews it shortly before it expires.
+type RefreshingCredential struct {
+	fetch  TokenFunc
+	leeway time.Duration
+	mu     sync.Mutex
+	token  string
+	expiry time.Time
+}
+
+// NewRefreshingCredential creates a provider that fetches tokens with fetch
+// and renews them leeway before they expire. A zero leeway means DefaultRefreshLeeway.
+func NewRefreshingCredential(fetch TokenFunc, leeway time.Duration) *RefreshingCredential {
+	if leeway == 0 {
+		leeway = DefaultRefreshLeeway
+	}
+	return &RefreshingCredential{fetch: fetch, leeway: leeway}
+}
+
+// Credential implements CredentialProvider.
+func (c *RefreshingCredential) Credential(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.token != "" && (c.expiry.IsZero() || time.Until(c.expiry) > c.leeway) {
+		return c.token, nil
+	}
+
+	token, expiry, err := c.fetch(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to refresh credential: %w", err)
+	}
+	if token == "" {
+		return "", fmt.Errorf("%w: token source returned an empty token", ErrNoCredential)
+	}
+
+	c.token, c.expiry = token, expiry
+	return c.token, nil
+}
+
+// Invalidate implements CredentialInvalidator by forcing the next call to fetch a new token.
+func (c *RefreshingCredential) Invalidate(credential string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.token == credential {
+		c.token = ""
+	}
+}
+
+// credentials returns the provider currently used by the client.
+func (c *Client) credentials() CredentialProvider {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.credentialProvider
+}
+
+// credentialError marks an error returned by the credential provider, so that
+// it is not mistaken for a failure to reach the API.
+type credentialError struct {
+	err error
+}
+
+func (e *credentialError) Error() string {
+	return e.err.Error()
+}
+
+func (e *credentialError) Unwrap() error {
+	return e.err
+}
+
+// authorize sets the Authorization header on req and returns the credential used.
+func (c *Client) authorize(req *http.Request) (string, error) {
+	provider := c.credentials()
+	if provider == nil {
+		return "", nil
+	}
+
+	credential, err := provider.Credential(req.Context())
+	if err != nil {
+		return "", &credentialError{err: err}
+	}
+	if credential != "" {
+		req.Header.Set("Authorization", "Bearer "+credential)
+	}
+	return credential, nil
+}
+
+// reauthorize handles a 401 response by invalidating the rejected credential.
+// It returns a copy of req carrying a fresh credential, or nil if there is no
+// different credential to retry with.
+func (c *Client) reauthorize(req *http.Request, rejected string) *http.Request {
+	provider := c.credentials()
+	if provider == nil {
+		return nil
+	}
+	if invalidator, ok := provider.(CredentialInvalidator); ok {
+		invalidator.Invalidate(rejected)
+	}
+
+	retry, err := replay(req)
+	if err != nil {
+		return nil
+	}
+
+	credential, err := c.authorize(retry)
+	if err != nil || credential == rejected {
+		return nil
+	}
+	return retry
+}
@@ -0,0 +1,184 @@
+package tama_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	tama "github.com/upmaru/tama-go"
+)
+
+func TestStaticCredential(t *testing.T) {
+	key, err := tama.StaticCredential("static-key").Credential(context.Background())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if key != "static-key" {
+		t.Errorf("Expected static-key, got %s", key)
+	}
+}
+
+func TestEnvCredential(t *testing.T) {
+	t.Setenv("TAMA_TEST_CREDENTIAL", "env-key")
+
+	key, err := tama.EnvCredential("TAMA_TEST_CREDENTIAL").Credential(context.Background())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if key != "env-key" {
+		t.Errorf("Expected env-key, got %s", key)
+	}
+
+	_, err = tama.EnvCredential("TAMA_TEST_CREDENTIAL_UNSET").Credential(context.Background())
+	if !errors.Is(err, tama.ErrNoCredential) {
+		t.Errorf("Expected ErrNoCredential for unset variable, got %v", err)
+	}
+}
+
+func TestFileCredentialReloadsOnChange(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "api-key")
+	if err := os.WriteFile(path, []byte("first-key\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	provider := tama.NewFileCredential(path)
+
+	key, err := provider.Credential(context.Background())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if key != "first-key" {
+		t.Errorf("Expected first-key, got %s", key)
+	}
+
+	if err := os.WriteFile(path, []byte("second-key\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	future := time.Now().Add(time.Hour)
+	if err := os.Chtimes(path, future, future); err != nil {
+		t.Fatal(err)
+	}
+
+	key, err = provider.Credential(context.Background())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if key != "second-key" {
+		t.Errorf("Expected second-key after rotation, got %s", key)
+	}
+}
+
+func TestRefreshingCredentialRenewsBeforeExpiry(t *testing.T) {
+	var fetches atomic.Int32
+	provider := tama.NewRefreshingCredential(func(_ context.Context) (string, time.Time, error) {
+		n := fetches.Add(1)
+		if n == 1 {
+			// Expires within the leeway, so the next call renews proactively.
+			return "token-1", time.Now().Add(30 * time.Second), nil
+		}
+		return "token-2", time.Now().Add(time.Hour), nil
+	}, time.Minute)
+
+	first, err := provider.Credential(context.Background())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	second, err := provider.Credential(context.Background())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	third, _ := provider.Credential(context.Background())
+
+	if first != "token-1" || second != "token-2" || third != "token-2" {
+		t.Errorf("Expected token-1, token-2, token-2, got %s, %s, %s", first, second, third)
+	}
+
+	if fetches.Load() != 2 {
+		t.Errorf("Expected 2 fetches, got %d", fetches.Load())
+	}
+}
+
+func TestUnauthorizedRetriesWithFreshCredential(t *testing.T) {
+	server := createMockServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token-2" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+	defer server.Close()
+
+	var fetches atomic.Int32
+	provider := tama.NewRefreshingCredential(func(_ context.Context) (string, time.Time, error) {
+		if fetches.Add(1) == 1 {
+			return "token-1", time.Time{}, nil
+		}
+		return "token-2", time.Time{}, nil
+	}, 0)
+
+	client := tama.NewClient(tama.Config{BaseURL: server.URL, Credentials: provider})
+
+	if err := client.Memory.DeletePrompt("prompt-123"); err != nil {
+		t.Fatalf("Expected retry with fresh credential to succeed, got %v", err)
+	}
+
+	if fetches.Load() != 2 {
+		t.Errorf("Expected 2 token fetches, got %d", fetches.Load())
+	}
+}
+
+func TestUnauthorizedWithStaticCredentialIsNotRetried(t *testing.T) {
+	var calls atomic.Int32
+	server := createMockServer(t, func(w http.ResponseWriter, _ *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusUnauthorized)
+	})
+	defer server.Close()
+
+	client := tama.NewClient(tama.Config{BaseURL: server.URL, APIKey: "bad-key"})
+
+	if err := client.Memory.DeletePrompt("prompt-123"); err == nil {
+		t.Fatal("Expected error, got nil")
+	}
+
+	if calls.Load() != 1 {
+		t.Errorf("Expected a single request, got %d", calls.Load())
+	}
+}
+
+func TestSetAPIKeyConcurrentWithRequests(t *testing.T) {
+	server := createMockServer(t, func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	defer server.Close()
+
+	client := tama.NewClient(tama.Config{BaseURL: server.URL, APIKey: "key-0"})
+
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			client.SetAPIKey("key-" + string(rune('a'+i)))
+		}()
+		go func() {
+			defer wg.Done()
+			if err := client.Neural.DeleteSpace("space-123"); err != nil {
+				t.Errorf("Expected no error, got %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,70 @@
+package tama
+
+// With returns a derived client that starts from c's current settings and
+// applies opts on top, e.g. to act on behalf of another tenant:
+//
+//	tenant, err := client.With(tama.WithAPIKey(tenantKey), tama.WithHeader("X-Tenant", tenantID))
+//
+// The derived client takes a snapshot of c's credentials, headers, debug mode
+// and middleware; later changes to either client do not affect the other. It
+// shares c's HTTP client, and with it the connection pool, unless opts set a
+// different HTTP client or TLS settings. Rate limiting, circuit breaker state
+// and the cache are kept per client, so tenants cannot see each other's cached
+// resources or exhaust each other's rate limits.
+//
+// Deriving a client is cheap and safe while c is in use. The result is
+// validated like NewClientWithOptions.
+func (c *Client) With(opts ...Option) (*Client, error) {
+	return c.derive(true, opts...)
+}
+
+// WithAPIKey returns a derived client that authenticates with apiKey and
+// otherwise behaves like c. See Client.With.
+func (c *Client) WithAPIKey(apiKey string) *Client {
+	// Changing only the key cannot make the settings invalid, so there is
+	// nothing to report even if c was created from unvalidated settings.
+	client, _ := c.derive(false, WithAPIKey(apiKey))
+	return client
+}
+
+// derive implements With. Unless validate is set, the settings are used as is,
+// like NewClient does.
+func (c *Client) derive(validate bool, opts ...Option) (*Client, error) {
+	config := c.config
+	c.mu.RLock()
+	config.Credentials = c.credentialProvider
+	config.Headers = c.headers.Clone()
+	c.mu.RUnlock()
+	// The API key, user agent and API version are already part of the
+	// snapshot above.
+	baseURL, version := config.BaseURL, config.APIVersion
+	config.APIKey, config.UserAgent, config.APIVersion = "", "", ""
+
+	httpClient, tls := config.HTTPClient, config.TLS
+	for _, opt := range opts {
+		opt(&config)
+	}
+	if validate {
+		if err := config.validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	derived := c.httpClient
+	if config.HTTPClient != httpClient || config.TLS != tls {
+		var err error
+		if derived, err = configHTTPClient(config); err != nil {
+			return nil, err
+		}
+	}
+
+	client := newClient(config, derived)
+	if config.BaseURL == baseURL && (config.APIVersion == "" || config.APIVersion == version) {
+		client.capabilities = c.capabilities
+	}
+	client.lifecycle = newLifecycle(c.lifecycle)
+	client.debug.Store(c.debug.Load())
+	client.debugLog = c.debugLog
+	client.middleware.use(c.middleware.snapshot()...)
+	return client, nil
+}
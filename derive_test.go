@@ -0,0 +1,205 @@
+package tama_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	tama "github.com/upmaru/tama-go"
+)
+
+// requestRecorder is a handler that records the headers and remote address of
+// every request and answers with a space.
+type requestRecorder struct {
+	mu      sync.Mutex
+	headers []http.Header
+	remotes []string
+}
+
+func (rec *requestRecorder) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rec.mu.Lock()
+	rec.headers = append(rec.headers, r.Header.Clone())
+	rec.remotes = append(rec.remotes, r.RemoteAddr)
+	rec.mu.Unlock()
+	spaceHandler(w, r)
+}
+
+func (rec *requestRecorder) last() http.Header {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	return rec.headers[len(rec.headers)-1]
+}
+
+func TestClientWith(t *testing.T) {
+	rec := &requestRecorder{}
+	server := createMockServer(t, rec.ServeHTTP)
+	defer server.Close()
+
+	parent := tama.NewClient(tama.Config{BaseURL: server.URL, APIKey: "parent-key", UserAgent: "app/1.0"})
+	parent.SetHeader("X-Region", "eu")
+
+	tenant, err := parent.With(tama.WithAPIKey("tenant-key"), tama.WithHeader("X-Tenant", "acme"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// Changes to the parent after deriving do not reach the derived client.
+	parent.SetAPIKey("rotated-key")
+	parent.SetHeader("X-Region", "us")
+
+	if _, err := tenant.Neural.GetSpace("space-123"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	header := rec.last()
+	if got := header.Get("Authorization"); got != "Bearer tenant-key" {
+		t.Errorf("Expected tenant key, got %q", got)
+	}
+	if header.Get("X-Tenant") != "acme" || header.Get("X-Region") != "eu" || header.Get("User-Agent") != "app/1.0" {
+		t.Errorf("Expected tenant headers on top of the parent's, got %v", header)
+	}
+
+	if _, err := parent.Neural.GetSpace("space-123"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	header = rec.last()
+	if header.Get("Authorization") != "Bearer rotated-key" ||
+		header.Get("X-Tenant") != "" || header.Get("X-Region") != "us" {
+		t.Errorf("Expected parent settings unaffected by the derived client, got %v", header)
+	}
+}
+
+func TestClientWithSharesConnections(t *testing.T) {
+	rec := &requestRecorder{}
+	server := createMockServer(t, rec.ServeHTTP)
+	defer server.Close()
+
+	parent := tama.NewClient(tama.Config{BaseURL: server.URL, APIKey: "parent-key"})
+	if _, err := parent.Neural.GetSpace("space-123"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := parent.WithAPIKey("tenant-key").Neural.GetSpace("space-123"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if rec.remotes[0] != rec.remotes[1] {
+		t.Errorf("Expected the derived client to reuse the parent's connection, got %v", rec.remotes)
+	}
+}
+
+func TestClientWithSeparateCache(t *testing.T) {
+	rec := &requestRecorder{}
+	server := createMockServer(t, rec.ServeHTTP)
+	defer server.Close()
+
+	parent := tama.NewClient(tama.Config{
+		BaseURL: server.URL,
+		APIKey:  "parent-key",
+		Cache:   &tama.CacheConfig{DefaultTTL: time.Minute},
+	})
+	tenant := parent.WithAPIKey("tenant-key")
+
+	for _, client := range []*tama.Client{parent, tenant, parent, tenant} {
+		if _, err := client.Neural.GetSpace("space-123"); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+
+	if len(rec.headers) != 2 {
+		t.Errorf("Expected one request per client, got %d", len(rec.headers))
+	}
+	if stats := tenant.CacheStats(); stats.Hits != 1 || stats.Misses != 1 {
+		t.Errorf("Expected the tenant to have its own cache, got %+v", stats)
+	}
+}
+
+func TestClientWithInheritsMiddleware(t *testing.T) {
+	server := createMockServer(t, spaceHandler)
+	defer server.Close()
+
+	var calls atomic.Int32
+	parent := tama.NewClient(tama.Config{BaseURL: server.URL, APIKey: "parent-key"})
+	parent.Use(func(next tama.Handler) tama.Handler {
+		return func(req *tama.Request) (*http.Response, error) {
+			calls.Add(1)
+			return next(req)
+		}
+	})
+
+	if _, err := parent.WithAPIKey("tenant-key").Neural.GetSpace("space-123"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("Expected the parent's middleware to run, got %d calls", calls.Load())
+	}
+}
+
+func TestClientWithValidation(t *testing.T) {
+	parent := tama.NewClient(tama.Config{BaseURL: "https://api.tama.io", APIKey: "parent-key"})
+
+	_, err := parent.With(tama.WithBaseURL("ftp://api.tama.io"))
+	var configErr *tama.ConfigError
+	if !errors.As(err, &configErr) || configErr.Source != "BaseURL" {
+		t.Errorf("Expected BaseURL ConfigError, got %v", err)
+	}
+}
+
+func TestClientConcurrentTenants(t *testing.T) {
+	var mismatches atomic.Int32
+	server := createMockServer(t, func(w http.ResponseWriter, r *http.Request) {
+		tenant := r.Header.Get("X-Tenant")
+		if tenant != "" && r.Header.Get("Authorization") != "Bearer key-"+tenant {
+			mismatches.Add(1)
+		}
+		spaceHandler(w, r)
+	})
+	defer server.Close()
+
+	parent := tama.NewClient(tama.Config{BaseURL: server.URL, APIKey: "parent-key"})
+
+	var wg sync.WaitGroup
+	for i := range 8 {
+		tenantID := fmt.Sprintf("tenant-%d", i)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			tenant, err := parent.With(tama.WithAPIKey("key-"+tenantID), tama.WithHeader("X-Tenant", tenantID))
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			for range 20 {
+				if _, err := tenant.Neural.GetSpace("space-123"); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}()
+	}
+
+	// Mutate the shared parent while the tenants are busy.
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := range 20 {
+			parent.SetAPIKey(fmt.Sprintf("parent-key-%d", i))
+			parent.SetHeader("X-Region", fmt.Sprintf("region-%d", i))
+			parent.SetDebug(i%2 == 0)
+			parent.Use(func(next tama.Handler) tama.Handler { return next })
+			_ = parent.WithAPIKey("short-lived")
+			_ = parent.CacheStats()
+			if _, err := parent.Neural.GetSpace("space-123"); err != nil {
+				t.Error(err)
+				return
+			}
+		}
+	}()
+	wg.Wait()
+
+	if n := mismatches.Load(); n > 0 {
+		t.Errorf("Expected every tenant request to carry its own key, got %d mismatches", n)
+	}
+}
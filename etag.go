@@ -0,0 +1,249 @@
+package tama
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+
+	"github.com/upmaru/tama-go/memory"
+	"github.com/upmaru/tama-go/neural"
+	"github.com/upmaru/tama-go/sensory"
+)
+
+// DefaultModifyAttempts is the number of times a Modify helper runs its
+// read-modify-write cycle before giving up on a conflict.
+const DefaultModifyAttempts = 5
+
+// PreconditionFailedError is returned when the API answers 412 Precondition
+// Failed because the resource changed since the ETag sent with If-Match was
+// issued.
+type PreconditionFailedError struct {
+	// ETag is the entity tag that was sent with If-Match.
+	ETag string
+	// CurrentETag is the entity tag of the current resource, if the API returned it.
+	CurrentETag string
+}
+
+func (e *PreconditionFailedError) Error() string {
+	return fmt.Sprintf("precondition failed: resource no longer matches ETag %s", e.ETag)
+}
+
+type ifMatchKey struct{}
+
+// WithIfMatch returns a copy of ctx that makes update, replace and delete
+// calls issued with it conditional on the resource still having the given
+// ETag. If it does not, the call fails with a *PreconditionFailedError. An
+// empty etag leaves the call unconditional.
+func WithIfMatch(ctx context.Context, etag string) context.Context {
+	return context.WithValue(ctx, ifMatchKey{}, etag)
+}
+
+// ifMatch returns the ETag requested through the context of a write request.
+func ifMatch(req *http.Request) string {
+	if req.Method == http.MethodGet || req.Method == http.MethodPost {
+		return ""
+	}
+	etag, _ := req.Context().Value(ifMatchKey{}).(string)
+	return etag
+}
+
+// setIfMatch adds the If-Match header requested through the context of a write request.
+func setIfMatch(req *http.Request) {
+	if etag := ifMatch(req); etag != "" {
+		req.Header.Set("If-Match", etag)
+	}
+}
+
+// preconditionFailed converts a 412 response into a *PreconditionFailedError.
+func preconditionFailed(req *http.Request, resp *http.Response) error {
+	drain(resp)
+	return &PreconditionFailedError{ETag: req.Header.Get("If-Match"), CurrentETag: resp.Header.Get("ETag")}
+}
+
+// ErrNoETag is returned by the Modify helpers when the API returns a resource
+// without an ETag, so that its write cannot be made conditional.
+var ErrNoETag = errors.New("resource has no ETag")
+
+// ErrCannotClear is returned by the Modify helpers when change empties a field
+// that was set. Updates leave out empty fields, so the API would keep the old
+// value.
+var ErrCannotClear = errors.New("update cannot clear field")
+
+// modifier describes how a Modify helper reads and writes a resource type.
+type modifier[T any] struct {
+	get func(ctx context.Context) (*T, error)
+	// etag returns the ETag of a resource.
+	etag func(*T) string
+	// set returns the names of the fields of a resource that its update sends,
+	// i.e. those that are not empty.
+	set func(*T) []string
+	// put writes a resource with the given context.
+	put func(ctx context.Context, resource *T) (*T, error)
+}
+
+// modify runs a read-modify-write cycle, starting over with a fresh read
+// whenever the write fails with a *PreconditionFailedError.
+func modify[T any](ctx context.Context, m modifier[T], change func(*T) error) (*T, error) {
+	var err error
+	for range DefaultModifyAttempts {
+		var resource *T
+		if resource, err = m.get(ctx); err != nil {
+			return nil, err
+		}
+		etag := m.etag(resource)
+		if etag == "" {
+			return nil, ErrNoETag
+		}
+		before := m.set(resource)
+		if err = change(resource); err != nil {
+			return nil, err
+		}
+		after := m.set(resource)
+		for _, field := range before {
+			if !slices.Contains(after, field) {
+				return nil, fmt.Errorf("%w: %s", ErrCannotClear, field)
+			}
+		}
+
+		var updated *T
+		updated, err = m.put(WithIfMatch(ctx, etag), resource)
+		var conflict *PreconditionFailedError
+		if !errors.As(err, &conflict) {
+			return updated, err
+		}
+	}
+	return nil, err
+}
+
+// setFields returns the names of the fields whose values are set.
+func setFields(fields map[string]bool) []string {
+	var names []string
+	for name, set := range fields {
+		if set {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	return names
+}
+
+// ModifySpace reads a space, passes it to change and writes the result back
+// with If-Match set to the ETag of the read. If the space changed in the
+// meantime the cycle starts over, up to DefaultModifyAttempts times. An error
+// returned by change aborts the cycle and is returned as is.
+//
+// The write fails with ErrNoETag if the API did not return an ETag, and with
+// ErrCannotClear if change empties a field that was set, as updates cannot
+// clear fields.
+func (s *NeuralService) ModifySpace(
+	ctx context.Context, id string, change func(*neural.Space) error,
+) (*neural.Space, error) {
+	return modify(ctx, modifier[neural.Space]{
+		get:  func(ctx context.Context) (*neural.Space, error) { return s.GetSpaceWithContext(ctx, id) },
+		etag: func(space *neural.Space) string { return space.ETag },
+		set: func(space *neural.Space) []string {
+			return setFields(map[string]bool{"name": space.Name != "", "type": space.Type != ""})
+		},
+		put: func(ctx context.Context, space *neural.Space) (*neural.Space, error) {
+			return s.UpdateSpaceWithContext(ctx, id, neural.UpdateSpaceRequest{
+				Space: neural.UpdateSpaceData{Name: space.Name, Type: space.Type},
+			})
+		},
+	}, change)
+}
+
+// ModifySource is like NeuralService.ModifySpace for sources.
+func (s *SensoryService) ModifySource(
+	ctx context.Context, id string, change func(*sensory.Source) error,
+) (*sensory.Source, error) {
+	return modify(ctx, modifier[sensory.Source]{
+		get:  func(ctx context.Context) (*sensory.Source, error) { return s.GetSourceWithContext(ctx, id) },
+		etag: func(source *sensory.Source) string { return source.ETag },
+		set: func(source *sensory.Source) []string {
+			return setFields(map[string]bool{"name": source.Name != "", "endpoint": source.Endpoint != ""})
+		},
+		put: func(ctx context.Context, source *sensory.Source) (*sensory.Source, error) {
+			return s.UpdateSourceWithContext(ctx, id, sensory.UpdateSourceRequest{
+				Source: sensory.UpdateSourceData{Name: source.Name, Endpoint: source.Endpoint},
+			})
+		},
+	}, change)
+}
+
+// ModifyModel is like NeuralService.ModifySpace for models.
+func (s *SensoryService) ModifyModel(
+	ctx context.Context, id string, change func(*sensory.Model) error,
+) (*sensory.Model, error) {
+	return modify(ctx, modifier[sensory.Model]{
+		get:  func(ctx context.Context) (*sensory.Model, error) { return s.GetModelWithContext(ctx, id) },
+		etag: func(model *sensory.Model) string { return model.ETag },
+		set: func(model *sensory.Model) []string {
+			return setFields(map[string]bool{
+				"identifier": model.Identifier != "",
+				"path":       model.Path != "",
+				"parameters": len(model.Parameters) > 0,
+			})
+		},
+		put: func(ctx context.Context, model *sensory.Model) (*sensory.Model, error) {
+			return s.UpdateModelWithContext(ctx, id, sensory.UpdateModelRequest{
+				Model: sensory.UpdateModelData{
+					Identifier: model.Identifier,
+					Path:       model.Path,
+					Parameters: model.Parameters,
+				},
+			})
+		},
+	}, change)
+}
+
+// ModifyLimit is like NeuralService.ModifySpace for limits.
+func (s *SensoryService) ModifyLimit(
+	ctx context.Context, id string, change func(*sensory.Limit) error,
+) (*sensory.Limit, error) {
+	return modify(ctx, modifier[sensory.Limit]{
+		get:  func(ctx context.Context) (*sensory.Limit, error) { return s.GetLimitWithContext(ctx, id) },
+		etag: func(limit *sensory.Limit) string { return limit.ETag },
+		set: func(limit *sensory.Limit) []string {
+			return setFields(map[string]bool{
+				"scale_unit":    limit.ScaleUnit != "",
+				"scale_count":   limit.ScaleCount != 0,
+				"count":         limit.Count != 0,
+				"current_state": limit.CurrentState != "",
+			})
+		},
+		put: func(ctx context.Context, limit *sensory.Limit) (*sensory.Limit, error) {
+			return s.UpdateLimitWithContext(ctx, id, sensory.UpdateLimitRequest{
+				Limit: sensory.UpdateLimitData{
+					ScaleUnit:    limit.ScaleUnit,
+					ScaleCount:   limit.ScaleCount,
+					Count:        limit.Count,
+					CurrentState: limit.CurrentState,
+				},
+			})
+		},
+	}, change)
+}
+
+// ModifyPrompt is like NeuralService.ModifySpace for prompts.
+func (s *MemoryService) ModifyPrompt(
+	ctx context.Context, id string, change func(*memory.Prompt) error,
+) (*memory.Prompt, error) {
+	return modify(ctx, modifier[memory.Prompt]{
+		get:  func(ctx context.Context) (*memory.Prompt, error) { return s.GetPromptWithContext(ctx, id) },
+		etag: func(prompt *memory.Prompt) string { return prompt.ETag },
+		set: func(prompt *memory.Prompt) []string {
+			return setFields(map[string]bool{
+				"name":    prompt.Name != "",
+				"content": prompt.Content != "",
+				"role":    prompt.Role != "",
+			})
+		},
+		put: func(ctx context.Context, prompt *memory.Prompt) (*memory.Prompt, error) {
+			return s.UpdatePromptWithContext(ctx, id, memory.UpdatePromptRequest{
+				Prompt: memory.UpdatePromptData{Name: prompt.Name, Content: prompt.Content, Role: prompt.Role},
+			})
+		},
+	}, change)
+}
@@ -0,0 +1,182 @@
+package tama_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	tama "github.com/upmaru/tama-go"
+	"github.com/upmaru/tama-go/memory"
+)
+
+// promptStore serves a single prompt whose ETag changes on every write.
+type promptStore struct {
+	mu       sync.Mutex
+	version  int
+	content  string
+	ifMatch  []string
+	onGet    func(s *promptStore)
+	getCalls int
+	noETag   bool
+}
+
+func (s *promptStore) etag() string {
+	return fmt.Sprintf(`"v%d"`, s.version)
+}
+
+func (s *promptStore) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	switch r.Method {
+	case http.MethodGet:
+		s.getCalls++
+		if s.onGet != nil {
+			defer s.onGet(s)
+		}
+	case http.MethodPatch:
+		s.ifMatch = append(s.ifMatch, r.Header.Get("If-Match"))
+		if match := r.Header.Get("If-Match"); match != "" && match != s.etag() {
+			w.Header().Set("ETag", s.etag())
+			w.WriteHeader(http.StatusPreconditionFailed)
+			return
+		}
+		var req memory.UpdatePromptRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		s.content = req.Prompt.Content
+		s.version++
+	}
+
+	if !s.noETag {
+		w.Header().Set("ETag", s.etag())
+	}
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"data": map[string]any{"id": "prompt-123", "name": "Prompt", "content": s.content, "role": "system"},
+	})
+}
+
+func TestETagCapturedAndIfMatchSent(t *testing.T) {
+	store := &promptStore{version: 1, content: "original"}
+	server := createMockServer(t, store.ServeHTTP)
+	defer server.Close()
+
+	client := tama.NewClient(tama.Config{BaseURL: server.URL, APIKey: "test-key"})
+
+	prompt, err := client.Memory.GetPrompt("prompt-123")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if prompt.ETag != `"v1"` {
+		t.Fatalf("Expected ETag \"v1\", got %q", prompt.ETag)
+	}
+
+	ctx := tama.WithIfMatch(context.Background(), prompt.ETag)
+	update := memory.UpdatePromptRequest{Prompt: memory.UpdatePromptData{Content: "first"}}
+	updated, err := client.Memory.UpdatePromptWithContext(ctx, "prompt-123", update)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if updated.ETag != `"v2"` {
+		t.Errorf("Expected updated ETag \"v2\", got %q", updated.ETag)
+	}
+
+	// Writing again with the stale ETag must fail.
+	_, err = client.Memory.UpdatePromptWithContext(ctx, "prompt-123", update)
+	var conflict *tama.PreconditionFailedError
+	if !errors.As(err, &conflict) {
+		t.Fatalf("Expected PreconditionFailedError, got %v", err)
+	}
+	if conflict.ETag != `"v1"` || conflict.CurrentETag != `"v2"` {
+		t.Errorf("Unexpected conflict details: %+v", conflict)
+	}
+
+	if len(store.ifMatch) != 2 || store.ifMatch[0] != `"v1"` {
+		t.Errorf("Expected If-Match to be sent, got %v", store.ifMatch)
+	}
+}
+
+func TestModifyPromptRetriesOnConflict(t *testing.T) {
+	store := &promptStore{version: 1, content: "original"}
+	// Another writer changes the prompt right after the first read.
+	store.onGet = func(s *promptStore) {
+		if s.getCalls == 1 {
+			s.version++
+			s.content = "concurrent"
+		}
+	}
+	server := createMockServer(t, store.ServeHTTP)
+	defer server.Close()
+
+	client := tama.NewClient(tama.Config{BaseURL: server.URL, APIKey: "test-key"})
+
+	var seen []string
+	prompt, err := client.Memory.ModifyPrompt(context.Background(), "prompt-123", func(p *memory.Prompt) error {
+		seen = append(seen, p.Content)
+		p.Content += " + appended"
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if prompt.Content != "concurrent + appended" {
+		t.Errorf("Expected modification to apply to the latest version, got %q", prompt.Content)
+	}
+	if len(seen) != 2 || seen[1] != "concurrent" {
+		t.Errorf("Expected a second read after the conflict, got %v", seen)
+	}
+}
+
+func TestModifyPromptAbortsOnChangeError(t *testing.T) {
+	store := &promptStore{version: 1}
+	server := createMockServer(t, store.ServeHTTP)
+	defer server.Close()
+
+	client := tama.NewClient(tama.Config{BaseURL: server.URL, APIKey: "test-key"})
+
+	errAbort := errors.New("abort")
+	_, err := client.Memory.ModifyPrompt(context.Background(), "prompt-123", func(*memory.Prompt) error {
+		return errAbort
+	})
+	if !errors.Is(err, errAbort) {
+		t.Fatalf("Expected change error, got %v", err)
+	}
+	if len(store.ifMatch) != 0 {
+		t.Error("Expected no write after the change function failed")
+	}
+}
+
+func TestModifyPromptRejectsUnsendableWrites(t *testing.T) {
+	store := &promptStore{version: 1, content: "original"}
+	server := createMockServer(t, store.ServeHTTP)
+	defer server.Close()
+
+	client := tama.NewClient(tama.Config{BaseURL: server.URL, APIKey: "test-key"})
+
+	_, err := client.Memory.ModifyPrompt(context.Background(), "prompt-123", func(p *memory.Prompt) error {
+		p.Content = ""
+		return nil
+	})
+	if !errors.Is(err, tama.ErrCannotClear) || !strings.Contains(err.Error(), "content") {
+		t.Errorf("Expected clearing the content to be rejected, got %v", err)
+	}
+
+	store.noETag = true
+	_, err = client.Memory.ModifyPrompt(context.Background(), "prompt-123", func(p *memory.Prompt) error {
+		p.Content = "changed"
+		return nil
+	})
+	if !errors.Is(err, tama.ErrNoETag) {
+		t.Errorf("Expected a resource without an ETag to be rejected, got %v", err)
+	}
+
+	if len(store.ifMatch) != 0 {
+		t.Errorf("Expected no write, got %d", len(store.ifMatch))
+	}
+}
@@ -0,0 +1,64 @@
+package tama
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// IdempotencyKeyHeader is the request header that carries the idempotency key of a create call.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+type idempotencyKey struct{}
+
+// WithIdempotencyKey returns a copy of ctx that makes the create call issued
+// with it send key as its Idempotency-Key header. Reuse the same key when
+// retrying a create in your own code so the API can discard the duplicate.
+// Without a key, the client generates a random one per call.
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyKey{}, key)
+}
+
+// IdempotencyKeyFor derives a deterministic idempotency key from parts, such
+// as the parent ID and the create request, so that the same logical create
+// always sends the same key:
+//
+//	key, err := tama.IdempotencyKeyFor("sensory.CreateSource", spaceID, req)
+//	ctx = tama.WithIdempotencyKey(ctx, key)
+func IdempotencyKeyFor(parts ...any) (string, error) {
+	data, err := json.Marshal(parts)
+	if err != nil {
+		return "", fmt.Errorf("failed to derive idempotency key: %w", err)
+	}
+
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// setIdempotencyKey adds an Idempotency-Key header to POST requests. The key
+// is set once per call, so every retry of the call carries the same key.
+func setIdempotencyKey(req *http.Request) {
+	if req.Method != http.MethodPost || req.Header.Get(IdempotencyKeyHeader) != "" {
+		return
+	}
+
+	key, _ := req.Context().Value(idempotencyKey{}).(string)
+	if key == "" {
+		key = newIdempotencyKey()
+	}
+	req.Header.Set(IdempotencyKeyHeader, key)
+}
+
+// newIdempotencyKey returns a random version 4 UUID.
+func newIdempotencyKey() string {
+	var uuid [16]byte
+	_, _ = rand.Read(uuid[:])
+	uuid[6] = uuid[6]&0x0f | 0x40
+	uuid[8] = uuid[8]&0x3f | 0x80
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", uuid[0:4], uuid[4:6], uuid[6:8], uuid[8:10], uuid[10:16])
+}
@@ -0,0 +1,119 @@
+package tama_test
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+	"sync"
+	"testing"
+
+	tama "github.com/upmaru/tama-go"
+	"github.com/upmaru/tama-go/neural"
+)
+
+// keyRecorder records the Idempotency-Key header of every request and fails
+// the first attempt of each create with 503.
+type keyRecorder struct {
+	mu   sync.Mutex
+	keys []string
+}
+
+func (k *keyRecorder) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	k.mu.Lock()
+	k.keys = append(k.keys, r.Header.Get(tama.IdempotencyKeyHeader))
+	first := len(k.keys)%2 == 1
+	k.mu.Unlock()
+
+	if r.Method == http.MethodPost && first {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write([]byte(`{"data":{"id":"space-123","name":"Test Space","type":"root"}}`))
+}
+
+func TestIdempotencyKeyReusedAcrossRetries(t *testing.T) {
+	recorder := &keyRecorder{}
+	server := createMockServer(t, recorder.ServeHTTP)
+	defer server.Close()
+
+	policy := fastRetryPolicy()
+	policy.RetryNonIdempotent = true
+	client := tama.NewClient(tama.Config{BaseURL: server.URL, APIKey: "test-key", Retry: policy})
+
+	req := neural.CreateSpaceRequest{Space: neural.SpaceRequestData{Name: "Test Space", Type: "root"}}
+	for range 2 {
+		if _, err := client.Neural.CreateSpace(req); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+
+	if len(recorder.keys) != 4 {
+		t.Fatalf("Expected 4 attempts, got %d", len(recorder.keys))
+	}
+
+	uuid := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	if !uuid.MatchString(recorder.keys[0]) {
+		t.Errorf("Expected a generated UUID key, got %q", recorder.keys[0])
+	}
+	if recorder.keys[0] != recorder.keys[1] || recorder.keys[2] != recorder.keys[3] {
+		t.Errorf("Expected retries to reuse the key, got %v", recorder.keys)
+	}
+	if recorder.keys[0] == recorder.keys[2] {
+		t.Errorf("Expected separate creates to use different keys, got %v", recorder.keys)
+	}
+}
+
+func TestIdempotencyKeySuppliedByCaller(t *testing.T) {
+	recorder := &keyRecorder{}
+	server := createMockServer(t, recorder.ServeHTTP)
+	defer server.Close()
+
+	client := tama.NewClient(tama.Config{BaseURL: server.URL, APIKey: "test-key"})
+
+	req := neural.CreateSpaceRequest{Space: neural.SpaceRequestData{Name: "Test Space", Type: "root"}}
+	key, err := tama.IdempotencyKeyFor("neural.CreateSpace", req)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	ctx := tama.WithIdempotencyKey(context.Background(), key)
+	for range 2 {
+		// The first attempt fails with 503; the caller retries with the same key.
+		_, _ = client.Neural.CreateSpaceWithContext(ctx, req)
+	}
+	if _, err := client.Neural.GetSpace("space-123"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if recorder.keys[0] != key || recorder.keys[1] != key {
+		t.Errorf("Expected caller key %q on both creates, got %v", key, recorder.keys)
+	}
+	if recorder.keys[2] != "" {
+		t.Errorf("Expected no idempotency key on GET, got %q", recorder.keys[2])
+	}
+}
+
+func TestIdempotencyKeyForIsDeterministic(t *testing.T) {
+	req := neural.CreateSpaceRequest{Space: neural.SpaceRequestData{Name: "Test Space", Type: "root"}}
+
+	first, err := tama.IdempotencyKeyFor("neural.CreateSpace", req)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	second, _ := tama.IdempotencyKeyFor("neural.CreateSpace", req)
+	if first != second {
+		t.Errorf("Expected identical keys for identical content, got %s and %s", first, second)
+	}
+
+	req.Space.Name = "Other Space"
+	other, _ := tama.IdempotencyKeyFor("neural.CreateSpace", req)
+	if other == first {
+		t.Error("Expected different content to produce a different key")
+	}
+
+	if _, err := tama.IdempotencyKeyFor(make(chan int)); err == nil {
+		t.Error("Expected error for content that cannot be encoded")
+	}
+}
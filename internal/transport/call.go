@@ -0,0 +1,112 @@
+// Package transport holds per-call state that is shared between the service
+// packages (neural, sensory, memory) and the client's HTTP transport.
+package transport
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"unicode"
+)
+
+// Call carries the state of a single logical API call. A service attaches a
+// Call to the request context before sending it and the client transport
+// fills it in as the call progresses.
+type Call struct {
+	// Operation names the service method that issued the call, e.g. "sensory.CreateModel".
+	Operation string
+	// Route is the path template of the endpoint, e.g. "/provision/sensory/sources/{id}".
+	Route string
+	// ResourceID is the ID of the resource the call reads or modifies. It is
+	// empty for create calls.
+	ResourceID string
+	// ParentID is the ID of the parent resource a create call adds to, e.g.
+	// the space ID for "sensory.CreateSource".
+	ParentID string
+	// Attempts is the number of HTTP attempts made for the call, including retries.
+	Attempts int
+}
+
+// NewCall creates the state for a call made by operation against route. The
+// id fills the route's placeholder: it is the resource ID for reads and
+// mutations, and the parent ID for POST requests that create a resource.
+func NewCall(operation, method, route, id string) *Call {
+	call := &Call{Operation: operation, Route: route}
+	if method == http.MethodPost {
+		call.ParentID = id
+	} else {
+		call.ResourceID = id
+	}
+	return call
+}
+
+// Path expands the route template with the call's ID.
+func (c *Call) Path() string {
+	id := c.ResourceID
+	if id == "" {
+		id = c.ParentID
+	}
+
+	start := strings.IndexByte(c.Route, '{')
+	end := strings.IndexByte(c.Route, '}')
+	if start < 0 || end < start {
+		return c.Route
+	}
+	return c.Route[:start] + url.PathEscape(id) + c.Route[end+1:]
+}
+
+// Service returns the name of the service that issued the call, e.g. "sensory".
+func (c *Call) Service() string {
+	service, _, _ := strings.Cut(c.Operation, ".")
+	return service
+}
+
+// Action returns the kind of operation in lower case, e.g. "create" for
+// "sensory.CreateModel".
+func (c *Call) Action() string {
+	action, _ := c.split()
+	return strings.ToLower(action)
+}
+
+// Resource returns the resource type in lower case, e.g. "model" for
+// "sensory.CreateModel".
+func (c *Call) Resource() string {
+	_, resource := c.split()
+	return strings.ToLower(resource)
+}
+
+// ParentResource returns the resource type of the parent a create call adds
+// to, e.g. "space" for "/provision/sensory/spaces/{space_id}/sources".
+func (c *Call) ParentResource() string {
+	start := strings.IndexByte(c.Route, '{')
+	if start <= 0 {
+		return ""
+	}
+	collection := c.Route[strings.LastIndexByte(c.Route[:start-1], '/')+1 : start-1]
+	return strings.TrimSuffix(collection, "s")
+}
+
+// split divides the method name into its verb and resource parts.
+func (c *Call) split() (string, string) {
+	_, method, _ := strings.Cut(c.Operation, ".")
+	for i, r := range method {
+		if i > 0 && unicode.IsUpper(r) {
+			return method[:i], method[i:]
+		}
+	}
+	return method, ""
+}
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx that carries call.
+func NewContext(ctx context.Context, call *Call) context.Context {
+	return context.WithValue(ctx, contextKey{}, call)
+}
+
+// FromContext returns the Call attached to ctx, or nil if there is none.
+func FromContext(ctx context.Context) *Call {
+	call, _ := ctx.Value(contextKey{}).(*Call)
+	return call
+}
@@ -0,0 +1,127 @@
+package tama
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/upmaru/tama-go/internal/transport"
+)
+
+// newDebugLogger returns the logger used after SetDebug(true) by clients
+// without a Logger.
+func newDebugLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug}))
+}
+
+// logger returns the logger of the client and the minimum level it logs at,
+// or a nil logger if logging is off.
+func (c *Client) logger() (*slog.Logger, slog.Level) {
+	level := slog.LevelInfo
+	if c.logLevel != nil {
+		level = c.logLevel.Level()
+	}
+
+	debug := c.debug.Load()
+	if debug {
+		level = slog.LevelDebug
+	}
+
+	switch {
+	case c.log != nil:
+		return c.log, level
+	case debug:
+		return c.debugLog, level
+	default:
+		return nil, level
+	}
+}
+
+// enabledLogger returns the client's logger if it logs records at level.
+func (c *Client) enabledLogger(ctx context.Context, level slog.Level) *slog.Logger {
+	logger, minLevel := c.logger()
+	if logger == nil || level < minLevel || !logger.Enabled(ctx, level) {
+		return nil
+	}
+	return logger
+}
+
+// logCall logs the outcome of a call. Successful calls are logged at debug
+// level, 4xx responses at info level and other failures at warn level.
+func (c *Client) logCall(req *http.Request, resp *http.Response, err error, duration time.Duration) {
+	ctx := req.Context()
+
+	level, msg := slog.LevelDebug, "tama call completed"
+	switch {
+	case err != nil || resp.StatusCode >= http.StatusInternalServerError:
+		level, msg = slog.LevelWarn, "tama call failed"
+	case resp.StatusCode >= http.StatusBadRequest:
+		level, msg = slog.LevelInfo, "tama call failed"
+	}
+
+	logger := c.enabledLogger(ctx, level)
+	if logger == nil {
+		return
+	}
+
+	attrs := append(callAttrs(req), slog.Duration("duration", duration))
+	if resp != nil {
+		attrs = append(attrs, slog.Int("status", resp.StatusCode))
+	}
+	if err != nil {
+		attrs = append(attrs, slog.String("error", err.Error()))
+	}
+	logger.LogAttrs(ctx, level, msg, attrs...)
+}
+
+// logRequest logs a single attempt at debug level with redacted headers and body.
+func (c *Client) logRequest(req *http.Request) {
+	ctx := req.Context()
+	logger := c.enabledLogger(ctx, slog.LevelDebug)
+	if logger == nil {
+		return
+	}
+
+	var body []byte
+	if req.GetBody != nil {
+		if reader, err := req.GetBody(); err == nil {
+			body, _ = io.ReadAll(reader)
+		}
+	}
+
+	attrs := append(callAttrs(req),
+		slog.Any("header", redactHeader(req.Header)),
+		slog.String("body", string(redactBody(body))),
+	)
+	logger.LogAttrs(ctx, slog.LevelDebug, "tama request", attrs...)
+}
+
+// logResponse logs the response to a single attempt at debug level with
+// redacted headers and body. The body must already be buffered.
+func (c *Client) logResponse(req *http.Request, resp *http.Response, body []byte) {
+	ctx := req.Context()
+	logger := c.enabledLogger(ctx, slog.LevelDebug)
+	if logger == nil {
+		return
+	}
+
+	attrs := append(callAttrs(req),
+		slog.Int("status", resp.StatusCode),
+		slog.Any("header", redactHeader(resp.Header)),
+		slog.String("body", string(redactBody(bytes.TrimSpace(body)))),
+	)
+	logger.LogAttrs(ctx, slog.LevelDebug, "tama response", attrs...)
+}
+
+// callAttrs returns the attributes that identify the call req belongs to.
+func callAttrs(req *http.Request) []slog.Attr {
+	var attrs []slog.Attr
+	if call := transport.FromContext(req.Context()); call != nil {
+		attrs = append(attrs, slog.String("operation", call.Operation), slog.Int("attempt", call.Attempts))
+	}
+	return append(attrs, slog.String("method", req.Method), slog.String("path", req.URL.Path))
+}
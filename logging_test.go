@@ -0,0 +1,131 @@
+package tama_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+
+	tama "github.com/upmaru/tama-go"
+	"github.com/upmaru/tama-go/sensory"
+)
+
+func logRecords(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var records []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var record map[string]any
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("Expected JSON log record, got %q: %v", line, err)
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+func TestLoggerRedactsSecrets(t *testing.T) {
+	server := createMockServer(t, func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"data":{"id":"source-123","name":"Test","type":"model"}}`))
+	})
+	defer server.Close()
+
+	var buf bytes.Buffer
+	client := tama.NewClient(tama.Config{
+		BaseURL:  server.URL,
+		APIKey:   "secret-bearer-token",
+		Logger:   slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})),
+		LogLevel: slog.LevelDebug,
+	})
+
+	_, err := client.Sensory.CreateSource("space-123", sensory.CreateSourceRequest{
+		Source: sensory.SourceRequestData{
+			Name:       "Test",
+			Type:       "model",
+			Endpoint:   "https://api.example.com",
+			Credential: sensory.SourceCredential{APIKey: "secret-source-key"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	output := buf.String()
+	for _, secret := range []string{"secret-bearer-token", "secret-source-key"} {
+		if strings.Contains(output, secret) {
+			t.Errorf("Expected %s to be redacted from logs:\n%s", secret, output)
+		}
+	}
+	if !strings.Contains(output, "[REDACTED]") {
+		t.Errorf("Expected redaction marker in logs:\n%s", output)
+	}
+
+	records := logRecords(t, &buf)
+	if len(records) != 3 {
+		t.Fatalf("Expected request, response and call records, got %d:\n%s", len(records), output)
+	}
+
+	call := records[2]
+	if call["msg"] != "tama call completed" {
+		t.Errorf("Expected call record last, got %v", call["msg"])
+	}
+	if call["operation"] != "sensory.CreateSource" || call["path"] != "/provision/sensory/spaces/space-123/sources" {
+		t.Errorf("Unexpected operation or path: %v %v", call["operation"], call["path"])
+	}
+	if call["status"] != float64(http.StatusCreated) {
+		t.Errorf("Expected status 201, got %v", call["status"])
+	}
+	if _, ok := call["duration"]; !ok {
+		t.Error("Expected duration field")
+	}
+}
+
+func TestLoggerDefaultLevelLogsFailuresOnly(t *testing.T) {
+	server := createMockServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == http.MethodDelete {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"errors":{"detail":"Not Found"}}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":{"id":"prompt-123","title":"Test"}}`))
+	})
+	defer server.Close()
+
+	var buf bytes.Buffer
+	client := tama.NewClient(tama.Config{
+		BaseURL: server.URL,
+		APIKey:  "test-key",
+		Logger:  slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})),
+	})
+
+	if _, err := client.Memory.GetPrompt("prompt-123"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := client.Memory.DeletePrompt("prompt-123"); err == nil {
+		t.Fatal("Expected error for missing prompt")
+	}
+
+	records := logRecords(t, &buf)
+	if len(records) != 1 {
+		t.Fatalf("Expected only the failed call to be logged, got %d records", len(records))
+	}
+	if records[0]["level"] != "INFO" || records[0]["msg"] != "tama call failed" {
+		t.Errorf("Unexpected record: %v", records[0])
+	}
+
+	buf.Reset()
+	client.SetDebug(true)
+	if _, err := client.Memory.GetPrompt("prompt-123"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if records := logRecords(t, &buf); len(records) != 3 {
+		t.Errorf("Expected debug mode to log every attempt, got %d records", len(records))
+	}
+}
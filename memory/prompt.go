@@ -1,8 +1,10 @@
 package memory
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"net/http"
 )
 
 // This file contains all Prompt-related operations for the MemoryService.
@@ -13,14 +15,19 @@ import (
 // GetPrompt retrieves a specific prompt by ID.
 // GET /provision/memory/prompts/:id.
 func (s *Service) GetPrompt(id string) (*Prompt, error) {
+	return s.GetPromptWithContext(context.Background(), id)
+}
+
+// GetPromptWithContext is like GetPrompt but uses ctx for the request.
+// GET /provision/memory/prompts/:id.
+func (s *Service) GetPromptWithContext(ctx context.Context, id string) (*Prompt, error) {
 	if id == "" {
 		return nil, errors.New("prompt ID is required")
 	}
 
 	var promptResp PromptResponse
-	resp, err := s.client.R().
-		SetResult(&promptResp).
-		Get(fmt.Sprintf("/provision/memory/prompts/%s", id))
+	resp, err := s.send(ctx, "GetPrompt", http.MethodGet,
+		"/provision/memory/prompts/{id}", id, nil, &promptResp)
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to get prompt: %w", err)
@@ -36,6 +43,14 @@ func (s *Service) GetPrompt(id string) (*Prompt, error) {
 // CreatePrompt creates a new prompt in a specific space.
 // POST /provision/memory/spaces/:space_id/prompts.
 func (s *Service) CreatePrompt(spaceID string, req CreatePromptRequest) (*Prompt, error) {
+	return s.CreatePromptWithContext(context.Background(), spaceID, req)
+}
+
+// CreatePromptWithContext is like CreatePrompt but uses ctx for the request.
+// POST /provision/memory/spaces/:space_id/prompts.
+func (s *Service) CreatePromptWithContext(
+	ctx context.Context, spaceID string, req CreatePromptRequest,
+) (*Prompt, error) {
 	if spaceID == "" {
 		return nil, errors.New("space ID is required")
 	}
@@ -50,10 +65,8 @@ func (s *Service) CreatePrompt(spaceID string, req CreatePromptRequest) (*Prompt
 	}
 
 	var promptResp PromptResponse
-	resp, err := s.client.R().
-		SetBody(req).
-		SetResult(&promptResp).
-		Post(fmt.Sprintf("/provision/memory/spaces/%s/prompts", spaceID))
+	resp, err := s.send(ctx, "CreatePrompt", http.MethodPost,
+		"/provision/memory/spaces/{space_id}/prompts", spaceID, req, &promptResp)
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to create prompt: %w", err)
@@ -69,15 +82,19 @@ func (s *Service) CreatePrompt(spaceID string, req CreatePromptRequest) (*Prompt
 // UpdatePrompt updates an existing prompt using PATCH.
 // PATCH /provision/memory/prompts/:id.
 func (s *Service) UpdatePrompt(id string, req UpdatePromptRequest) (*Prompt, error) {
+	return s.UpdatePromptWithContext(context.Background(), id, req)
+}
+
+// UpdatePromptWithContext is like UpdatePrompt but uses ctx for the request.
+// PATCH /provision/memory/prompts/:id.
+func (s *Service) UpdatePromptWithContext(ctx context.Context, id string, req UpdatePromptRequest) (*Prompt, error) {
 	if id == "" {
 		return nil, errors.New("prompt ID is required")
 	}
 
 	var promptResp PromptResponse
-	resp, err := s.client.R().
-		SetBody(req).
-		SetResult(&promptResp).
-		Patch(fmt.Sprintf("/provision/memory/prompts/%s", id))
+	resp, err := s.send(ctx, "UpdatePrompt", http.MethodPatch,
+		"/provision/memory/prompts/{id}", id, req, &promptResp)
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to update prompt: %w", err)
@@ -93,15 +110,19 @@ func (s *Service) UpdatePrompt(id string, req UpdatePromptRequest) (*Prompt, err
 // ReplacePrompt replaces an existing prompt using PUT.
 // PUT /provision/memory/prompts/:id.
 func (s *Service) ReplacePrompt(id string, req UpdatePromptRequest) (*Prompt, error) {
+	return s.ReplacePromptWithContext(context.Background(), id, req)
+}
+
+// ReplacePromptWithContext is like ReplacePrompt but uses ctx for the request.
+// PUT /provision/memory/prompts/:id.
+func (s *Service) ReplacePromptWithContext(ctx context.Context, id string, req UpdatePromptRequest) (*Prompt, error) {
 	if id == "" {
 		return nil, errors.New("prompt ID is required")
 	}
 
 	var promptResp PromptResponse
-	resp, err := s.client.R().
-		SetBody(req).
-		SetResult(&promptResp).
-		Put(fmt.Sprintf("/provision/memory/prompts/%s", id))
+	resp, err := s.send(ctx, "ReplacePrompt", http.MethodPut,
+		"/provision/memory/prompts/{id}", id, req, &promptResp)
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to replace prompt: %w", err)
@@ -117,12 +138,18 @@ func (s *Service) ReplacePrompt(id string, req UpdatePromptRequest) (*Prompt, er
 // DeletePrompt deletes a prompt by ID.
 // DELETE /provision/memory/prompts/:id.
 func (s *Service) DeletePrompt(id string) error {
+	return s.DeletePromptWithContext(context.Background(), id)
+}
+
+// DeletePromptWithContext is like DeletePrompt but uses ctx for the request.
+// DELETE /provision/memory/prompts/:id.
+func (s *Service) DeletePromptWithContext(ctx context.Context, id string) error {
 	if id == "" {
 		return errors.New("prompt ID is required")
 	}
 
-	resp, err := s.client.R().
-		Delete(fmt.Sprintf("/provision/memory/prompts/%s", id))
+	resp, err := s.send(ctx, "DeletePrompt", http.MethodDelete,
+		"/provision/memory/prompts/{id}", id, nil, nil)
 
 	if err != nil {
 		return fmt.Errorf("failed to delete prompt: %w", err)
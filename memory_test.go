@@ -606,3 +606,24 @@ func TestMemoryCreatePromptWithFieldErrors(t *testing.T) {
 		t.Errorf("Expected error to contain 'content must be at least 10 characters', got %s", errorMsg)
 	}
 }
+
+func BenchmarkMemoryGetPrompt(b *testing.B) {
+	payload, _ := json.Marshal(memory.PromptResponse{
+		Data: memory.Prompt{ID: "prompt-123", Name: "bench-prompt", Content: "You are a helpful assistant.", Role: "system"},
+	})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(payload)
+	}))
+	defer server.Close()
+
+	client := tama.NewClient(tama.Config{BaseURL: server.URL, APIKey: "test-key"})
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for range b.N {
+		if _, err := client.Memory.GetPrompt("prompt-123"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package tama
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/upmaru/tama-go/internal/transport"
+)
+
+// RequestIDHeader is the response header that carries the server's request ID.
+const RequestIDHeader = "X-Request-Id"
+
+// ResponseMeta describes the HTTP response to a call. Pass it to a call with
+// WithResponseMeta.
+type ResponseMeta struct {
+	// StatusCode is the status code of the final response, or zero if no
+	// response was received.
+	StatusCode int
+	// Header holds the headers of the final response.
+	Header http.Header
+	// RequestID is the value of the X-Request-Id response header.
+	RequestID string
+	// ServerTiming holds the metrics of the Server-Timing response header.
+	ServerTiming []ServerTiming
+	// Attempts is the number of HTTP attempts made, including retries.
+	Attempts int
+	// Duration is the time the call took on the client, including retries.
+	Duration time.Duration
+}
+
+// ServerTiming is a single metric of a Server-Timing header.
+type ServerTiming struct {
+	Name        string
+	Duration    time.Duration
+	Description string
+}
+
+type responseMetaKey struct{}
+
+// WithResponseMeta returns a copy of ctx that makes the next call issued with
+// it fill in meta, whether the call succeeds or fails:
+//
+//	var meta tama.ResponseMeta
+//	space, err := client.Neural.GetSpaceWithContext(tama.WithResponseMeta(ctx, &meta), id)
+//	log.Println(meta.RequestID)
+//
+// If several calls share the context, meta describes the last one.
+func WithResponseMeta(ctx context.Context, meta *ResponseMeta) context.Context {
+	return context.WithValue(ctx, responseMetaKey{}, meta)
+}
+
+// recordResponseMeta fills in the ResponseMeta requested through ctx, if any.
+func recordResponseMeta(ctx context.Context, resp *http.Response, duration time.Duration) {
+	meta, _ := ctx.Value(responseMetaKey{}).(*ResponseMeta)
+	if meta == nil {
+		return
+	}
+
+	*meta = ResponseMeta{Duration: duration}
+	if call := transport.FromContext(ctx); call != nil {
+		meta.Attempts = call.Attempts
+	}
+	if resp != nil {
+		meta.StatusCode = resp.StatusCode
+		meta.Header = resp.Header.Clone()
+		meta.RequestID = resp.Header.Get(RequestIDHeader)
+		meta.ServerTiming = parseServerTiming(resp.Header.Values("Server-Timing"))
+	}
+}
+
+// parseServerTiming parses Server-Timing header values such as
+// `db;dur=53.2, cache;desc="Cache Read";dur=23`.
+func parseServerTiming(values []string) []ServerTiming {
+	var timings []ServerTiming
+	for _, value := range values {
+		for _, metric := range strings.Split(value, ",") {
+			params := strings.Split(metric, ";")
+			timing := ServerTiming{Name: strings.TrimSpace(params[0])}
+			if timing.Name == "" {
+				continue
+			}
+
+			for _, param := range params[1:] {
+				key, val, _ := strings.Cut(param, "=")
+				val = strings.Trim(strings.TrimSpace(val), `"`)
+				switch strings.ToLower(strings.TrimSpace(key)) {
+				case "dur":
+					if ms, err := strconv.ParseFloat(val, 64); err == nil {
+						timing.Duration = time.Duration(ms * float64(time.Millisecond))
+					}
+				case "desc":
+					timing.Description = val
+				}
+			}
+			timings = append(timings, timing)
+		}
+	}
+	return timings
+}
@@ -0,0 +1,91 @@
+package tama_test
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	tama "github.com/upmaru/tama-go"
+)
+
+func TestResponseMetaCapturesResponse(t *testing.T) {
+	var calls atomic.Int32
+	server := createMockServer(t, func(w http.ResponseWriter, _ *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Request-Id", "req-abc")
+		w.Header().Set("X-RateLimit-Remaining", "42")
+		w.Header().Add("Server-Timing", `db;dur=53.5, cache;desc="Cache Read";dur=2`)
+		_, _ = w.Write([]byte(`{"data":{"id":"space-123","name":"Test Space","type":"root"}}`))
+	})
+	defer server.Close()
+
+	client := tama.NewClient(tama.Config{BaseURL: server.URL, APIKey: "test-key", Retry: fastRetryPolicy()})
+
+	var meta tama.ResponseMeta
+	ctx := tama.WithResponseMeta(context.Background(), &meta)
+	if _, err := client.Neural.GetSpaceWithContext(ctx, "space-123"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if meta.StatusCode != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", meta.StatusCode)
+	}
+	if meta.RequestID != "req-abc" {
+		t.Errorf("Expected request ID req-abc, got %q", meta.RequestID)
+	}
+	if meta.Header.Get("X-RateLimit-Remaining") != "42" {
+		t.Errorf("Expected rate-limit header, got %v", meta.Header)
+	}
+	if meta.Attempts != 2 {
+		t.Errorf("Expected 2 attempts, got %d", meta.Attempts)
+	}
+	if meta.Duration <= 0 {
+		t.Error("Expected a positive duration")
+	}
+
+	expected := []tama.ServerTiming{
+		{Name: "db", Duration: 53500 * time.Microsecond},
+		{Name: "cache", Duration: 2 * time.Millisecond, Description: "Cache Read"},
+	}
+	if len(meta.ServerTiming) != len(expected) {
+		t.Fatalf("Expected %d server timings, got %v", len(expected), meta.ServerTiming)
+	}
+	for i, timing := range expected {
+		if meta.ServerTiming[i] != timing {
+			t.Errorf("Expected server timing %+v, got %+v", timing, meta.ServerTiming[i])
+		}
+	}
+}
+
+func TestResponseMetaOnError(t *testing.T) {
+	server := createMockServer(t, func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("X-Request-Id", "req-missing")
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"errors":{"detail":"Not Found"}}`))
+	})
+	defer server.Close()
+
+	client := tama.NewClient(tama.Config{BaseURL: server.URL, APIKey: "test-key"})
+
+	var sourceMeta, promptMeta tama.ResponseMeta
+	_, err := client.Sensory.GetSourceWithContext(tama.WithResponseMeta(context.Background(), &sourceMeta), "x")
+	if err == nil {
+		t.Fatal("Expected error for missing source")
+	}
+	err = client.Memory.DeletePromptWithContext(tama.WithResponseMeta(context.Background(), &promptMeta), "x")
+	if err == nil {
+		t.Fatal("Expected error for missing prompt")
+	}
+
+	for _, meta := range []tama.ResponseMeta{sourceMeta, promptMeta} {
+		if meta.StatusCode != http.StatusNotFound || meta.RequestID != "req-missing" || meta.Attempts != 1 {
+			t.Errorf("Unexpected metadata for failed call: %+v", meta)
+		}
+	}
+}
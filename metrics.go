@@ -0,0 +1,279 @@
+package tama
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"expvar"
+	"fmt"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/upmaru/tama-go/internal/transport"
+)
+
+// Error classes reported to Metrics.
+const (
+	// ErrorClassClient is a 4xx response other than 429.
+	ErrorClassClient = "client_error"
+	// ErrorClassServer is a 5xx response.
+	ErrorClassServer = "server_error"
+	// ErrorClassRateLimited is a 429 response or a call held back by the client-side rate limit.
+	ErrorClassRateLimited = "rate_limited"
+	// ErrorClassCircuitOpen is a call rejected by an open circuit breaker.
+	ErrorClassCircuitOpen = "circuit_open"
+	// ErrorClassTimeout is a call that ran out of time.
+	ErrorClassTimeout = "timeout"
+	// ErrorClassCanceled is a call cancelled by the caller.
+	ErrorClassCanceled = "canceled"
+	// ErrorClassNetwork is a failure to reach the API, such as a refused
+	// connection, a DNS error or a dropped connection.
+	ErrorClassNetwork = "network"
+	// ErrorClassCredential is a call that failed because the credential
+	// provider could not supply a credential.
+	ErrorClassCredential = "credential"
+	// ErrorClassLocal is any other failure on the client's side, such as
+	// invalid TLS settings, a public key pin mismatch or a request body that
+	// could not be compressed or sent again.
+	ErrorClassLocal = "local"
+)
+
+// StatusClassError is the status class of calls that got no response.
+const StatusClassError = "error"
+
+// latencyBuckets returns the upper bounds, in seconds, of the latency
+// histogram buckets used by ExpvarMetrics.
+func latencyBuckets() []float64 {
+	return []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+}
+
+// MetricLabels identify the endpoint a measurement belongs to.
+type MetricLabels struct {
+	// Service is "neural", "sensory" or "memory".
+	Service string
+	// Resource is "space", "source", "model", "limit" or "prompt".
+	Resource string
+	// Operation is "get", "create", "update", "replace" or "delete".
+	Operation string
+}
+
+// CallOutcome describes a finished call.
+type CallOutcome struct {
+	// StatusClass is the class of the final status code, e.g. "2xx" or "5xx",
+	// or StatusClassError when no response was received.
+	StatusClass string
+	// ErrorClass is one of the ErrorClass constants, or empty on success.
+	ErrorClass string
+	// Duration is the time the call took, including retries.
+	Duration time.Duration
+}
+
+// Metrics receives measurements for every API call. Implementations must be
+// safe for concurrent use. The tamaprom package provides a Prometheus
+// implementation.
+type Metrics interface {
+	// CallStarted is called when a call begins.
+	CallStarted(labels MetricLabels)
+	// CallFinished is called exactly once for every started call.
+	CallFinished(labels MetricLabels, outcome CallOutcome)
+}
+
+// finishFunc reports the outcome of a call that took d.
+type finishFunc func(ctx context.Context, resp *http.Response, err error, d time.Duration)
+
+// observe reports the start of the call carried by ctx to the client's
+// metrics and returns a function that reports its outcome.
+func (c *Client) observe(ctx context.Context) finishFunc {
+	var labels MetricLabels
+	if call := transport.FromContext(ctx); call != nil {
+		labels = MetricLabels{Service: call.Service(), Resource: call.Resource(), Operation: call.Action()}
+	}
+
+	c.metrics.CallStarted(labels)
+
+	return func(ctx context.Context, resp *http.Response, err error, d time.Duration) {
+		outcome := CallOutcome{Duration: d, StatusClass: StatusClassError}
+		if resp != nil {
+			outcome.StatusClass = strconv.Itoa(resp.StatusCode)[:1] + "xx"
+		}
+		outcome.ErrorClass = errorClass(ctx, resp, err)
+		c.metrics.CallFinished(labels, outcome)
+	}
+}
+
+// errorClass classifies the outcome of a call, returning an empty string on success.
+func errorClass(ctx context.Context, resp *http.Response, err error) string {
+	var (
+		limited    *RateLimitedError
+		credential *credentialError
+	)
+	switch {
+	case err == nil && resp.StatusCode == http.StatusTooManyRequests:
+		return ErrorClassRateLimited
+	case err == nil && resp.StatusCode >= http.StatusInternalServerError:
+		return ErrorClassServer
+	case err == nil && resp.StatusCode >= http.StatusBadRequest:
+		return ErrorClassClient
+	case err == nil:
+		return ""
+	case errors.As(err, &limited):
+		return ErrorClassRateLimited
+	case errors.Is(err, ErrCircuitOpen):
+		return ErrorClassCircuitOpen
+	case callerGaveUp(ctx) && errors.Is(context.Cause(ctx), context.Canceled):
+		return ErrorClassCanceled
+	case errors.As(err, &credential):
+		return ErrorClassCredential
+	case ctx.Err() != nil || errors.Is(err, context.DeadlineExceeded):
+		return ErrorClassTimeout
+	case isNetworkError(err):
+		return ErrorClassNetwork
+	default:
+		return ErrorClassLocal
+	}
+}
+
+// isNetworkError reports whether err is a failure to exchange a request with
+// the API, as opposed to a failure of the client's own settings.
+func isNetworkError(err error) bool {
+	var (
+		netErr    net.Error
+		pin       *PinMismatchError
+		verify    *tls.CertificateVerificationError
+		configErr *ConfigError
+	)
+	return errors.As(err, &netErr) &&
+		!errors.As(err, &pin) && !errors.As(err, &verify) && !errors.As(err, &configErr)
+}
+
+// expvar names are process-wide, so the metrics published under "tama" are too.
+var (
+	defaultMetricsOnce sync.Once      //nolint:gochecknoglobals // guards the process-wide expvar registration
+	defaultMetrics     *ExpvarMetrics //nolint:gochecknoglobals // published once with expvar, shared by all clients
+)
+
+// DefaultMetrics returns the metrics used by clients without Config.Metrics.
+// They are published with expvar under the name "tama".
+func DefaultMetrics() *ExpvarMetrics {
+	defaultMetricsOnce.Do(func() {
+		defaultMetrics = NewExpvarMetrics()
+		expvar.Publish("tama", defaultMetrics)
+	})
+	return defaultMetrics
+}
+
+// ExpvarMetrics is a Metrics implementation whose values can be published
+// with expvar. Its JSON form has the sections "requests_total",
+// "errors_total", "in_flight" and "latency_seconds", each keyed by
+// "service.resource.operation", with the status or error class appended for
+// the counters.
+type ExpvarMetrics struct {
+	requests expvar.Map
+	errors   expvar.Map
+	inFlight expvar.Map
+	mu       sync.Mutex
+	latency  map[string]*histogram
+}
+
+// NewExpvarMetrics creates an unpublished ExpvarMetrics. Publish it with
+// expvar.Publish to expose it, or use DefaultMetrics.
+func NewExpvarMetrics() *ExpvarMetrics {
+	return &ExpvarMetrics{latency: make(map[string]*histogram)}
+}
+
+// CallStarted implements Metrics.
+func (m *ExpvarMetrics) CallStarted(labels MetricLabels) {
+	m.inFlight.Add(labels.key(), 1)
+}
+
+// CallFinished implements Metrics.
+func (m *ExpvarMetrics) CallFinished(labels MetricLabels, outcome CallOutcome) {
+	key := labels.key()
+	m.inFlight.Add(key, -1)
+	m.requests.Add(key+"."+outcome.StatusClass, 1)
+	if outcome.ErrorClass != "" {
+		m.errors.Add(key+"."+outcome.ErrorClass, 1)
+	}
+	m.histogram(key).observe(outcome.Duration.Seconds())
+}
+
+// String implements expvar.Var.
+func (m *ExpvarMetrics) String() string {
+	m.mu.Lock()
+	latency := make([]string, 0, len(m.latency))
+	for key, h := range m.latency {
+		latency = append(latency, strconv.Quote(key)+": "+h.String())
+	}
+	m.mu.Unlock()
+	slices.Sort(latency)
+
+	return fmt.Sprintf(`{"requests_total": %s, "errors_total": %s, "in_flight": %s, "latency_seconds": {%s}}`,
+		m.requests.String(), m.errors.String(), m.inFlight.String(), strings.Join(latency, ", "))
+}
+
+// histogram returns the latency histogram for key, creating it on first use.
+func (m *ExpvarMetrics) histogram(key string) *histogram {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	h, ok := m.latency[key]
+	if !ok {
+		bounds := latencyBuckets()
+		h = &histogram{bounds: bounds, counts: make([]atomic.Int64, len(bounds)+1)}
+		m.latency[key] = h
+	}
+	return h
+}
+
+// key joins the labels into an expvar map key.
+func (l MetricLabels) key() string {
+	return l.Service + "." + l.Resource + "." + l.Operation
+}
+
+// histogram counts observations in buckets with the given upper bounds. The
+// last count holds observations above the largest bound.
+type histogram struct {
+	bounds []float64
+	counts []atomic.Int64
+	mu     sync.Mutex
+	sum    float64
+}
+
+// observe records a value in seconds.
+func (h *histogram) observe(seconds float64) {
+	i := 0
+	for i < len(h.bounds) && seconds > h.bounds[i] {
+		i++
+	}
+	h.counts[i].Add(1)
+
+	h.mu.Lock()
+	h.sum += seconds
+	h.mu.Unlock()
+}
+
+// String formats the histogram as JSON with cumulative bucket counts.
+func (h *histogram) String() string {
+	buckets := make([]string, 0, len(h.counts))
+	var total int64
+	for i := range h.counts {
+		total += h.counts[i].Load()
+		bound := "+Inf"
+		if i < len(h.bounds) {
+			bound = strconv.FormatFloat(h.bounds[i], 'g', -1, 64)
+		}
+		buckets = append(buckets, fmt.Sprintf("%q: %d", bound, total))
+	}
+
+	h.mu.Lock()
+	sum := h.sum
+	h.mu.Unlock()
+
+	return fmt.Sprintf(`{"buckets": {%s}, "count": %d, "sum": %g}`, strings.Join(buckets, ", "), total, sum)
+}
@@ -0,0 +1,110 @@
+package tama_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	tama "github.com/upmaru/tama-go"
+)
+
+type expvarSnapshot struct {
+	Requests map[string]int64 `json:"requests_total"`
+	Errors   map[string]int64 `json:"errors_total"`
+	InFlight map[string]int64 `json:"in_flight"`
+	Latency  map[string]struct {
+		Buckets map[string]int64 `json:"buckets"`
+		Count   int64            `json:"count"`
+	} `json:"latency_seconds"`
+}
+
+func snapshot(t *testing.T, metrics *tama.ExpvarMetrics) expvarSnapshot {
+	t.Helper()
+	var snap expvarSnapshot
+	if err := json.Unmarshal([]byte(metrics.String()), &snap); err != nil {
+		t.Fatalf("Expected metrics to be valid JSON: %v\n%s", err, metrics.String())
+	}
+	return snap
+}
+
+func TestExpvarMetricsRecordsCalls(t *testing.T) {
+	server := createMockServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == http.MethodDelete {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"errors":{"detail":"Not Found"}}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":{"id":"space-123","name":"Test Space","type":"root"}}`))
+	})
+	defer server.Close()
+
+	metrics := tama.NewExpvarMetrics()
+	client := tama.NewClient(tama.Config{BaseURL: server.URL, APIKey: "test-key", Metrics: metrics})
+
+	for range 2 {
+		if _, err := client.Neural.GetSpace("space-123"); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	if err := client.Neural.DeleteSpace("space-123"); err == nil {
+		t.Fatal("Expected error for missing space")
+	}
+
+	snap := snapshot(t, metrics)
+	if snap.Requests["neural.space.get.2xx"] != 2 {
+		t.Errorf("Expected 2 successful gets, got %v", snap.Requests)
+	}
+	if snap.Requests["neural.space.delete.4xx"] != 1 {
+		t.Errorf("Expected 1 failed delete, got %v", snap.Requests)
+	}
+	if snap.Errors["neural.space.delete."+tama.ErrorClassClient] != 1 {
+		t.Errorf("Expected 1 client error, got %v", snap.Errors)
+	}
+	if snap.InFlight["neural.space.get"] != 0 {
+		t.Errorf("Expected no calls in flight, got %v", snap.InFlight)
+	}
+	if latency := snap.Latency["neural.space.get"]; latency.Count != 2 || latency.Buckets["+Inf"] != 2 {
+		t.Errorf("Expected 2 latency observations, got %+v", latency)
+	}
+}
+
+func TestExpvarMetricsClassifiesErrors(t *testing.T) {
+	server := createMockServer(t, func(_ http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	})
+	defer server.Close()
+
+	metrics := tama.NewExpvarMetrics()
+	client := tama.NewClient(tama.Config{
+		BaseURL: server.URL,
+		APIKey:  "test-key",
+		Timeout: 20 * time.Millisecond,
+		Metrics: metrics,
+	})
+
+	if _, err := client.Memory.GetPrompt("prompt-123"); err == nil {
+		t.Fatal("Expected timeout error")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := client.Memory.GetPromptWithContext(ctx, "prompt-123")
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected context.Canceled, got %v", err)
+	}
+
+	snap := snapshot(t, metrics)
+	if snap.Errors["memory.prompt.get."+tama.ErrorClassTimeout] != 1 {
+		t.Errorf("Expected 1 timeout, got %v", snap.Errors)
+	}
+	if snap.Errors["memory.prompt.get."+tama.ErrorClassCanceled] != 1 {
+		t.Errorf("Expected 1 cancellation, got %v", snap.Errors)
+	}
+	if snap.Requests["memory.prompt.get."+tama.StatusClassError] != 2 {
+		t.Errorf("Expected 2 calls without a response, got %v", snap.Requests)
+	}
+}
@@ -0,0 +1,179 @@
+package tama
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/upmaru/tama-go/internal/transport"
+)
+
+// Request describes an outgoing API call as seen by middleware.
+//
+// Middleware may modify the method, path, headers and body before passing the
+// request on; the modified values are what gets sent to the server.
+type Request struct {
+	// Operation names the service method that issued the call, e.g. "sensory.CreateModel".
+	Operation string
+	Method    string
+	// Path is the URL path relative to the API base URL, e.g. "/provision/neural/spaces/space-123".
+	// The path of the base URL, if any, is added back when the request is sent.
+	Path   string
+	Header http.Header
+	// Body is the encoded request body, or nil if the request has none.
+	Body []byte
+
+	raw *http.Request
+}
+
+// Context returns the context of the call.
+func (r *Request) Context() context.Context {
+	return r.raw.Context()
+}
+
+// Handler sends a Request and returns the server's response.
+type Handler func(req *Request) (*http.Response, error)
+
+// Middleware wraps a Handler with cross-cutting behaviour such as extra
+// headers, logging or fault injection. Middleware that reads the response body
+// must replace it with an equivalent reader so that the caller can decode it.
+type Middleware func(next Handler) Handler
+
+// Use appends middleware to the chain that every call from the Neural, Sensory
+// and Memory services passes through. Middleware runs in the order it was
+// added, with the first one being the outermost. When a retry policy is
+// configured, the chain runs once per attempt.
+func (c *Client) Use(middleware ...Middleware) {
+	c.middleware.use(middleware...)
+}
+
+// middlewareTransport is an http.RoundTripper that runs requests through the
+// client's middleware chain.
+type middlewareTransport struct {
+	next http.RoundTripper
+	// prefix is the path of the API base URL, which middleware does not see.
+	prefix     string
+	mu         sync.RWMutex
+	middleware []Middleware
+}
+
+// newMiddlewareTransport creates a middleware transport that sends requests
+// with next. Request paths are shown to middleware without the path of
+// baseURL.
+func newMiddlewareTransport(next http.RoundTripper, baseURL string) *middlewareTransport {
+	var prefix string
+	if u, err := url.Parse(baseURL); err == nil {
+		prefix = strings.TrimSuffix(u.Path, "/")
+	}
+	return &middlewareTransport{next: next, prefix: prefix}
+}
+
+// use appends middleware to the chain.
+func (t *middlewareTransport) use(middleware ...Middleware) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.middleware = append(t.middleware, middleware...)
+}
+
+// snapshot returns a copy of the chain.
+func (t *middlewareTransport) snapshot() []Middleware {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return slices.Clone(t.middleware)
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *middlewareTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	middleware := t.snapshot()
+
+	if len(middleware) == 0 {
+		return t.next.RoundTrip(req)
+	}
+
+	body, err := readBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var operation string
+	if call := transport.FromContext(req.Context()); call != nil {
+		operation = call.Operation
+	}
+
+	handler := t.send
+	for i := len(middleware) - 1; i >= 0; i-- {
+		handler = middleware[i](handler)
+	}
+
+	return handler(&Request{
+		Operation: operation,
+		Method:    req.Method,
+		Path:      strings.TrimPrefix(req.URL.Path, t.prefix),
+		Header:    req.Header.Clone(),
+		Body:      body,
+		raw:       req,
+	})
+}
+
+// send is the innermost Handler; it rebuilds the HTTP request from r and sends it.
+func (t *middlewareTransport) send(r *Request) (*http.Response, error) {
+	out := r.raw.Clone(r.raw.Context())
+	out.Method = r.Method
+	if path := t.prefix + r.Path; out.URL.Path != path {
+		out.URL.Path = path
+		out.URL.RawPath = ""
+	}
+	out.Header = r.Header
+	setBody(out, r.Body)
+
+	return t.next.RoundTrip(out)
+}
+
+// readBody returns a copy of the request body without consuming it.
+func readBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+
+	var body io.ReadCloser = req.Body
+	if req.GetBody != nil {
+		var err error
+		if body, err = req.GetBody(); err != nil {
+			return nil, fmt.Errorf("failed to read request body: %w", err)
+		}
+	}
+	defer body.Close()
+
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read request body: %w", err)
+	}
+	if req.GetBody == nil {
+		setBody(req, data)
+	}
+	return data, nil
+}
+
+// setBody replaces the request body with data.
+func setBody(req *http.Request, data []byte) {
+	if data == nil {
+		req.Body = http.NoBody
+		req.GetBody = nil
+		req.ContentLength = 0
+		return
+	}
+
+	req.Body = io.NopCloser(bytes.NewReader(data))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(data)), nil
+	}
+	req.ContentLength = int64(len(data))
+}
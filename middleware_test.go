@@ -0,0 +1,194 @@
+package tama_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	tama "github.com/upmaru/tama-go"
+	"github.com/upmaru/tama-go/sensory"
+)
+
+func TestMiddlewareSeesCall(t *testing.T) {
+	server := createMockServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Tenant") != "tenant-1" {
+			t.Errorf("Expected X-Tenant header tenant-1, got %q", r.Header.Get("X-Tenant"))
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(sensory.ModelResponse{Data: sensory.Model{ID: "model-1", Identifier: "gpt-4"}})
+	})
+	defer server.Close()
+
+	client := tama.NewClient(tama.Config{BaseURL: server.URL, APIKey: "test-key"})
+
+	var seen tama.Request
+	var status int
+	client.Use(func(next tama.Handler) tama.Handler {
+		return func(req *tama.Request) (*http.Response, error) {
+			req.Header.Set("X-Tenant", "tenant-1")
+			seen = *req
+
+			resp, err := next(req)
+			if resp != nil {
+				status = resp.StatusCode
+			}
+			return resp, err
+		}
+	})
+
+	model, err := client.Sensory.CreateModel("source-123", sensory.CreateModelRequest{
+		Model: sensory.ModelRequestData{Identifier: "gpt-4", Path: "/chat/completions"},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if model.ID != "model-1" {
+		t.Errorf("Expected model ID model-1, got %s", model.ID)
+	}
+
+	if seen.Operation != "sensory.CreateModel" {
+		t.Errorf("Expected operation sensory.CreateModel, got %s", seen.Operation)
+	}
+
+	if seen.Method != http.MethodPost {
+		t.Errorf("Expected method POST, got %s", seen.Method)
+	}
+
+	if seen.Path != "/provision/sensory/sources/source-123/models" {
+		t.Errorf("Expected path /provision/sensory/sources/source-123/models, got %s", seen.Path)
+	}
+
+	if !strings.Contains(string(seen.Body), `"identifier":"gpt-4"`) {
+		t.Errorf("Expected body to contain identifier, got %s", seen.Body)
+	}
+
+	if status != http.StatusCreated {
+		t.Errorf("Expected middleware to see status 201, got %d", status)
+	}
+}
+
+func TestMiddlewarePathRelativeToBaseURL(t *testing.T) {
+	var received string
+	server := createMockServer(t, func(w http.ResponseWriter, r *http.Request) {
+		received = r.URL.Path
+		w.WriteHeader(http.StatusNoContent)
+	})
+	defer server.Close()
+
+	client := tama.NewClient(tama.Config{BaseURL: server.URL + "/api/", APIKey: "test-key"})
+
+	var seen string
+	client.Use(func(next tama.Handler) tama.Handler {
+		return func(req *tama.Request) (*http.Response, error) {
+			seen = req.Path
+			req.Path = strings.Replace(req.Path, "prompt-1", "prompt-2", 1)
+			return next(req)
+		}
+	})
+
+	if err := client.Memory.DeletePrompt("prompt-1"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if seen != "/provision/memory/prompts/prompt-1" {
+		t.Errorf("Expected the path without the base URL path, got %s", seen)
+	}
+
+	if received != "/api/provision/memory/prompts/prompt-2" {
+		t.Errorf("Expected the base URL path to be added back, got %s", received)
+	}
+}
+
+func TestMiddlewareOrder(t *testing.T) {
+	server := createMockServer(t, func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"data": {"id": "source-1"}}`))
+	})
+	defer server.Close()
+
+	client := tama.NewClient(tama.Config{BaseURL: server.URL, APIKey: "test-key"})
+
+	var order []string
+	for _, name := range []string{"first", "second"} {
+		client.Use(func(next tama.Handler) tama.Handler {
+			return func(req *tama.Request) (*http.Response, error) {
+				order = append(order, name)
+				return next(req)
+			}
+		})
+	}
+
+	if _, err := client.Sensory.GetSource("source-1"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if strings.Join(order, ",") != "first,second" {
+		t.Errorf("Expected middleware order first,second, got %v", order)
+	}
+}
+
+func TestMiddlewareFaultInjection(t *testing.T) {
+	server := createMockServer(t, func(_ http.ResponseWriter, _ *http.Request) {
+		t.Error("Expected request to be short-circuited by middleware")
+	})
+	defer server.Close()
+
+	client := tama.NewClient(tama.Config{BaseURL: server.URL, APIKey: "test-key"})
+
+	injected := errors.New("injected fault")
+	client.Use(func(_ tama.Handler) tama.Handler {
+		return func(_ *tama.Request) (*http.Response, error) {
+			return nil, injected
+		}
+	})
+
+	err := client.Sensory.DeleteLimit("limit-1")
+	if !errors.Is(err, injected) {
+		t.Fatalf("Expected injected fault, got %v", err)
+	}
+}
+
+func TestMiddlewareReadsResponseBody(t *testing.T) {
+	server := createMockServer(t, func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"data": {"id": "limit-1", "count": 5}}`))
+	})
+	defer server.Close()
+
+	client := tama.NewClient(tama.Config{BaseURL: server.URL, APIKey: "test-key"})
+
+	var body []byte
+	client.Use(func(next tama.Handler) tama.Handler {
+		return func(req *tama.Request) (*http.Response, error) {
+			resp, err := next(req)
+			if err != nil {
+				return nil, err
+			}
+
+			body, _ = io.ReadAll(resp.Body)
+			resp.Body.Close()
+			resp.Body = io.NopCloser(bytes.NewReader(body))
+			return resp, nil
+		}
+	})
+
+	limit, err := client.Sensory.GetLimit("limit-1")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if limit.Count != 5 {
+		t.Errorf("Expected limit count 5, got %d", limit.Count)
+	}
+
+	if !strings.Contains(string(body), "limit-1") {
+		t.Errorf("Expected middleware to read response body, got %s", body)
+	}
+}
@@ -1,21 +1,28 @@
 package neural
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"net/http"
 )
 
 // GetSpace retrieves a specific space by ID.
 // GET /provision/neural/spaces/:id.
 func (s *Service) GetSpace(id string) (*Space, error) {
+	return s.GetSpaceWithContext(context.Background(), id)
+}
+
+// GetSpaceWithContext is like GetSpace but uses ctx for the request.
+// GET /provision/neural/spaces/:id.
+func (s *Service) GetSpaceWithContext(ctx context.Context, id string) (*Space, error) {
 	if id == "" {
 		return nil, errors.New("space ID is required")
 	}
 
 	var spaceResp SpaceResponse
-	resp, err := s.client.R().
-		SetResult(&spaceResp).
-		Get(fmt.Sprintf("/provision/neural/spaces/%s", id))
+	resp, err := s.send(ctx, "GetSpace", http.MethodGet,
+		"/provision/neural/spaces/{id}", id, nil, &spaceResp)
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to get space: %w", err)
@@ -31,6 +38,12 @@ func (s *Service) GetSpace(id string) (*Space, error) {
 // CreateSpace creates a new space.
 // POST /provision/neural/spaces.
 func (s *Service) CreateSpace(req CreateSpaceRequest) (*Space, error) {
+	return s.CreateSpaceWithContext(context.Background(), req)
+}
+
+// CreateSpaceWithContext is like CreateSpace but uses ctx for the request.
+// POST /provision/neural/spaces.
+func (s *Service) CreateSpaceWithContext(ctx context.Context, req CreateSpaceRequest) (*Space, error) {
 	if req.Space.Name == "" {
 		return nil, errors.New("space name is required")
 	}
@@ -42,10 +55,8 @@ func (s *Service) CreateSpace(req CreateSpaceRequest) (*Space, error) {
 	}
 
 	var spaceResp SpaceResponse
-	resp, err := s.client.R().
-		SetBody(req).
-		SetResult(&spaceResp).
-		Post("/provision/neural/spaces")
+	resp, err := s.send(ctx, "CreateSpace", http.MethodPost,
+		"/provision/neural/spaces", "", req, &spaceResp)
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to create space: %w", err)
@@ -61,15 +72,19 @@ func (s *Service) CreateSpace(req CreateSpaceRequest) (*Space, error) {
 // UpdateSpace updates an existing space using PATCH.
 // PATCH /provision/neural/spaces/:id.
 func (s *Service) UpdateSpace(id string, req UpdateSpaceRequest) (*Space, error) {
+	return s.UpdateSpaceWithContext(context.Background(), id, req)
+}
+
+// UpdateSpaceWithContext is like UpdateSpace but uses ctx for the request.
+// PATCH /provision/neural/spaces/:id.
+func (s *Service) UpdateSpaceWithContext(ctx context.Context, id string, req UpdateSpaceRequest) (*Space, error) {
 	if id == "" {
 		return nil, errors.New("space ID is required")
 	}
 
 	var spaceResp SpaceResponse
-	resp, err := s.client.R().
-		SetBody(req).
-		SetResult(&spaceResp).
-		Patch(fmt.Sprintf("/provision/neural/spaces/%s", id))
+	resp, err := s.send(ctx, "UpdateSpace", http.MethodPatch,
+		"/provision/neural/spaces/{id}", id, req, &spaceResp)
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to update space: %w", err)
@@ -85,15 +100,19 @@ func (s *Service) UpdateSpace(id string, req UpdateSpaceRequest) (*Space, error)
 // ReplaceSpace replaces an existing space using PUT.
 // PUT /provision/neural/spaces/:id.
 func (s *Service) ReplaceSpace(id string, req UpdateSpaceRequest) (*Space, error) {
+	return s.ReplaceSpaceWithContext(context.Background(), id, req)
+}
+
+// ReplaceSpaceWithContext is like ReplaceSpace but uses ctx for the request.
+// PUT /provision/neural/spaces/:id.
+func (s *Service) ReplaceSpaceWithContext(ctx context.Context, id string, req UpdateSpaceRequest) (*Space, error) {
 	if id == "" {
 		return nil, errors.New("space ID is required")
 	}
 
 	var spaceResp SpaceResponse
-	resp, err := s.client.R().
-		SetBody(req).
-		SetResult(&spaceResp).
-		Put(fmt.Sprintf("/provision/neural/spaces/%s", id))
+	resp, err := s.send(ctx, "ReplaceSpace", http.MethodPut,
+		"/provision/neural/spaces/{id}", id, req, &spaceResp)
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to replace space: %w", err)
@@ -109,12 +128,18 @@ func (s *Service) ReplaceSpace(id string, req UpdateSpaceRequest) (*Space, error
 // DeleteSpace deletes a space by ID.
 // DELETE /provision/neural/spaces/:id.
 func (s *Service) DeleteSpace(id string) error {
+	return s.DeleteSpaceWithContext(context.Background(), id)
+}
+
+// DeleteSpaceWithContext is like DeleteSpace but uses ctx for the request.
+// DELETE /provision/neural/spaces/:id.
+func (s *Service) DeleteSpaceWithContext(ctx context.Context, id string) error {
 	if id == "" {
 		return errors.New("space ID is required")
 	}
 
-	resp, err := s.client.R().
-		Delete(fmt.Sprintf("/provision/neural/spaces/%s", id))
+	resp, err := s.send(ctx, "DeleteSpace", http.MethodDelete,
+		"/provision/neural/spaces/{id}", id, nil, nil)
 
 	if err != nil {
 		return fmt.Errorf("failed to delete space: %w", err)
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"errors"
 	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 
@@ -285,3 +286,24 @@ func TestNeuralDeleteSpace(t *testing.T) {
 		t.Fatalf("Expected no error, got %v", err)
 	}
 }
+
+func BenchmarkNeuralGetSpace(b *testing.B) {
+	payload, _ := json.Marshal(neural.SpaceResponse{
+		Data: neural.Space{ID: "space-123", Name: "bench-space", Type: "root", CurrentState: "active"},
+	})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(payload)
+	}))
+	defer server.Close()
+
+	client := tama.NewClient(tama.Config{BaseURL: server.URL, APIKey: "test-key"})
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for range b.N {
+		if _, err := client.Neural.GetSpace("space-123"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
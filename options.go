@@ -0,0 +1,222 @@
+package tama
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Option configures a client created with NewClientWithOptions.
+type Option func(*Config)
+
+// NewClientWithOptions creates a new Tama API client from the given options,
+// applied in order on top of a zero Config. Unlike NewClient, it validates the
+// result: the base URL must be an absolute http or https URL, timeouts must
+// not be negative, the compression level must be valid and the TLS settings
+// must load. Invalid settings are reported as a *ConfigError.
+//
+//	client, err := tama.NewClientWithOptions(
+//		tama.WithBaseURL("https://api.tama.io"),
+//		tama.WithAPIKey(apiKey),
+//		tama.WithUserAgent("my-app/1.2"),
+//		tama.WithRetry(tama.RetryPolicy{MaxAttempts: 3}),
+//	)
+func NewClientWithOptions(opts ...Option) (*Client, error) {
+	var config Config
+	for _, opt := range opts {
+		opt(&config)
+	}
+
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+	httpClient, err := configHTTPClient(config)
+	if err != nil {
+		return nil, err
+	}
+	return newClient(config, httpClient), nil
+}
+
+// validate checks the settings that NewClient accepts without complaint but
+// that cannot work.
+func (config *Config) validate() error {
+	if err := validateBaseURL(config.BaseURL); err != nil {
+		return &ConfigError{Source: "BaseURL", Err: err}
+	}
+	if config.Timeout < 0 {
+		err := fmt.Errorf("timeout %s must not be negative", config.Timeout)
+		return &ConfigError{Source: "Timeout", Err: err}
+	}
+	if config.Timeouts != nil {
+		if err := config.Timeouts.validate(); err != nil {
+			return &ConfigError{Source: "Timeouts", Err: err}
+		}
+	}
+	if config.Compression != nil {
+		if err := config.Compression.validate(); err != nil {
+			return &ConfigError{Source: "Compression", Err: err}
+		}
+	}
+	return nil
+}
+
+// configHTTPClient returns the HTTP client described by config, with its TLS
+// settings applied.
+func configHTTPClient(config Config) (*http.Client, error) {
+	httpClient := config.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{}
+	}
+	if config.TLS == nil {
+		return httpClient, nil
+	}
+
+	httpClient, err := tlsHTTPClient(httpClient, config.TLS)
+	if err != nil {
+		return nil, &ConfigError{Source: "TLS", Err: err}
+	}
+	return httpClient, nil
+}
+
+// validateBaseURL checks that value is an absolute http or https URL.
+func validateBaseURL(value string) error {
+	if value == "" {
+		return ErrNoBaseURL
+	}
+
+	u, err := url.Parse(value)
+	if err != nil {
+		return err
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("base URL %q must be an absolute http or https URL", value)
+	}
+	return nil
+}
+
+// WithConfig replaces the configuration built so far with config, e.g. one
+// returned by LoadConfig. Options after it adjust the loaded settings.
+func WithConfig(config Config) Option {
+	return func(c *Config) {
+		*c = config
+		c.Headers = config.Headers.Clone()
+	}
+}
+
+// WithBaseURL sets the base URL of the API.
+func WithBaseURL(baseURL string) Option {
+	return func(c *Config) { c.BaseURL = baseURL }
+}
+
+// WithAPIKey sets a static API key, replacing any credential provider set
+// before it.
+func WithAPIKey(apiKey string) Option {
+	return func(c *Config) {
+		c.APIKey = apiKey
+		c.Credentials = nil
+	}
+}
+
+// WithCredentials sets the credential provider, which takes precedence over
+// an API key.
+func WithCredentials(provider CredentialProvider) Option {
+	return func(c *Config) { c.Credentials = provider }
+}
+
+// WithTimeout sets the timeout of every call. Zero means DefaultTimeout.
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *Config) { c.Timeout = timeout }
+}
+
+// WithTimeouts sets the timeouts per operation class and resource type.
+func WithTimeouts(policy TimeoutPolicy) Option {
+	return func(c *Config) { c.Timeouts = &policy }
+}
+
+// WithHTTPClient sets the HTTP client that sends the requests.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Config) { c.HTTPClient = httpClient }
+}
+
+// WithTLS sets the TLS settings of the client's connections.
+func WithTLS(config TLSConfig) Option {
+	return func(c *Config) { c.TLS = &config }
+}
+
+// WithAPIVersion sets the API version sent with every request.
+func WithAPIVersion(version string) Option {
+	return func(c *Config) { c.APIVersion = version }
+}
+
+// WithUserAgent sets the User-Agent header sent with every request.
+func WithUserAgent(userAgent string) Option {
+	return func(c *Config) { c.UserAgent = userAgent }
+}
+
+// WithHeader adds a header that is sent with every request. It can be given
+// several times, also for the same header.
+func WithHeader(header, value string) Option {
+	return func(c *Config) {
+		if c.Headers == nil {
+			c.Headers = make(http.Header)
+		}
+		c.Headers.Add(header, value)
+	}
+}
+
+// WithLogger sets the logger that receives records of every call.
+func WithLogger(logger *slog.Logger) Option {
+	return func(c *Config) { c.Logger = logger }
+}
+
+// WithLogLevel sets the minimum level of the records written to the logger.
+func WithLogLevel(level slog.Leveler) Option {
+	return func(c *Config) { c.LogLevel = level }
+}
+
+// WithRetry enables automatic retries with the given policy.
+func WithRetry(policy RetryPolicy) Option {
+	return func(c *Config) { c.Retry = &policy }
+}
+
+// WithRateLimit enables client-side request throttling.
+func WithRateLimit(limit RateLimit) Option {
+	return func(c *Config) { c.RateLimit = &limit }
+}
+
+// WithCircuitBreaker enables a circuit breaker per service.
+func WithCircuitBreaker(breaker CircuitBreakerConfig) Option {
+	return func(c *Config) { c.CircuitBreaker = &breaker }
+}
+
+// WithCache enables the read-through cache for Get calls.
+func WithCache(cache CacheConfig) Option {
+	return func(c *Config) { c.Cache = &cache }
+}
+
+// WithCompression enables compression of request and response bodies.
+func WithCompression(compression CompressionConfig) Option {
+	return func(c *Config) { c.Compression = &compression }
+}
+
+// WithOutbox sets the outbox that stores mutations made while the API is unreachable.
+func WithOutbox(outbox *Outbox) Option {
+	return func(c *Config) { c.Outbox = outbox }
+}
+
+// WithAudit enables the audit log of mutating calls.
+func WithAudit(audit AuditConfig) Option {
+	return func(c *Config) { c.Audit = &audit }
+}
+
+// WithTracer sets the tracer that starts a span for every call.
+func WithTracer(tracer Tracer) Option {
+	return func(c *Config) { c.Tracer = tracer }
+}
+
+// WithMetrics sets the recipient of call measurements.
+func WithMetrics(metrics Metrics) Option {
+	return func(c *Config) { c.Metrics = metrics }
+}
@@ -0,0 +1,108 @@
+package tama_test
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	tama "github.com/upmaru/tama-go"
+)
+
+func TestNewClientWithOptions(t *testing.T) {
+	var received http.Header
+	server := createMockServer(t, func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Clone()
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"data":{"id":"space-123"}}`))
+	})
+	defer server.Close()
+
+	client, err := tama.NewClientWithOptions(
+		tama.WithBaseURL(server.URL),
+		tama.WithAPIKey("test-key"),
+		tama.WithTimeout(5*time.Second),
+		tama.WithUserAgent("tama-test/1.0"),
+		tama.WithHeader("X-Tenant", "acme"),
+		tama.WithHTTPClient(server.Client()),
+	)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if _, err := client.Neural.GetSpace("space-123"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got := received.Get("User-Agent"); got != "tama-test/1.0" {
+		t.Errorf("Expected User-Agent tama-test/1.0, got %q", got)
+	}
+	if got := received.Get("X-Tenant"); got != "acme" {
+		t.Errorf("Expected X-Tenant acme, got %q", got)
+	}
+	if got := received.Get("Authorization"); got != "Bearer test-key" {
+		t.Errorf("Expected Authorization Bearer test-key, got %q", got)
+	}
+}
+
+func TestNewClientWithOptionsOverridesConfig(t *testing.T) {
+	var userAgent string
+	server := createMockServer(t, func(w http.ResponseWriter, r *http.Request) {
+		userAgent = r.Header.Get("User-Agent")
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"data":{"id":"space-123"}}`))
+	})
+	defer server.Close()
+
+	config := tama.Config{BaseURL: "https://unused.example.com", APIKey: "test-key", UserAgent: "base/1.0"}
+	client, err := tama.NewClientWithOptions(tama.WithConfig(config), tama.WithBaseURL(server.URL))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if _, err := client.Neural.GetSpace("space-123"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if userAgent != "base/1.0" {
+		t.Errorf("Expected User-Agent from config, got %q", userAgent)
+	}
+}
+
+func TestNewClientWithOptionsValidation(t *testing.T) {
+	tests := []struct {
+		name   string
+		opts   []tama.Option
+		source string
+	}{
+		{name: "missing base URL", opts: nil, source: "BaseURL"},
+		{name: "relative base URL", opts: []tama.Option{tama.WithBaseURL("api.tama.io")}, source: "BaseURL"},
+		{name: "unsupported scheme", opts: []tama.Option{tama.WithBaseURL("ftp://api.tama.io")}, source: "BaseURL"},
+		{name: "missing host", opts: []tama.Option{tama.WithBaseURL("https://")}, source: "BaseURL"},
+		{
+			name:   "negative timeout",
+			opts:   []tama.Option{tama.WithBaseURL("https://api.tama.io"), tama.WithTimeout(-time.Second)},
+			source: "Timeout",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := tama.NewClientWithOptions(tt.opts...)
+			if client != nil {
+				t.Error("Expected no client")
+			}
+
+			var configErr *tama.ConfigError
+			if !errors.As(err, &configErr) {
+				t.Fatalf("Expected ConfigError, got %v", err)
+			}
+			if configErr.Source != tt.source {
+				t.Errorf("Expected source %s, got %s", tt.source, configErr.Source)
+			}
+		})
+	}
+
+	_, err := tama.NewClientWithOptions()
+	if !errors.Is(err, tama.ErrNoBaseURL) {
+		t.Errorf("Expected ErrNoBaseURL, got %v", err)
+	}
+}
@@ -0,0 +1,523 @@
+package tama
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/upmaru/tama-go/internal/transport"
+)
+
+// QueuedError is returned by a mutation that failed because the API was
+// unreachable and that was stored in the outbox to be replayed later.
+type QueuedError struct {
+	// ID identifies the outbox entry.
+	ID uint64
+	// Err is the error the call failed with.
+	Err error
+}
+
+func (e *QueuedError) Error() string {
+	return fmt.Sprintf("queued as outbox entry %d: %v", e.ID, e.Err)
+}
+
+func (e *QueuedError) Unwrap() error {
+	return e.Err
+}
+
+// ErrOutboxPending is the error of a mutation that was queued without being
+// sent because earlier changes to the same resource are still in the outbox.
+var ErrOutboxPending = errors.New("earlier changes to the resource are pending in the outbox")
+
+// ErrReplayInProgress is returned by ReplayOutbox when another replay of the
+// same outbox has not finished yet.
+var ErrReplayInProgress = errors.New("outbox replay already in progress")
+
+// OutboxEntry is a mutation stored in the outbox.
+type OutboxEntry struct {
+	ID        uint64    `json:"id"`
+	Queued    time.Time `json:"queued"`
+	Operation string    `json:"operation"`
+	Method    string    `json:"method"`
+	// Route is the path template of the endpoint, e.g. "/provision/memory/prompts/{id}".
+	Route      string `json:"route"`
+	ResourceID string `json:"resource_id,omitempty"`
+	ParentID   string `json:"parent_id,omitempty"`
+	// Body is the JSON request body. It is stored as sent, without redaction.
+	Body           json.RawMessage `json:"body,omitempty"`
+	IdempotencyKey string          `json:"idempotency_key,omitempty"`
+	IfMatch        string          `json:"if_match,omitempty"`
+	// Error is the error the original call failed with.
+	Error string `json:"error"`
+}
+
+// Path returns the request path of the entry.
+func (e *OutboxEntry) Path() string {
+	call := transport.Call{Route: e.Route, ResourceID: e.ResourceID, ParentID: e.ParentID}
+	return call.Path()
+}
+
+// outboxRecord is a line of the outbox file: either a new entry or the
+// outcome of replaying one.
+type outboxRecord struct {
+	Entry *OutboxEntry `json:"entry,omitempty"`
+	Done  *outboxDone  `json:"done,omitempty"`
+}
+
+type outboxDone struct {
+	ID     uint64       `json:"id"`
+	Status ReplayStatus `json:"status"`
+}
+
+// Outbox persists mutations that failed because the API was unreachable, so
+// they can be replayed in order with Client.ReplayOutbox. Pass it to a client
+// in Config.Outbox.
+//
+// The outbox is an append-only JSON Lines file. Queued entries and the outcome
+// of replaying them are appended as they happen, so a crash loses at most the
+// line being written. Request bodies are stored unredacted, as they must be
+// sent again; the file is created readable by its owner only.
+//
+// Updates, replaces and deletes of a resource that has pending entries are
+// queued behind them instead of being sent, so that a replay never applies an
+// older change on top of a newer one.
+//
+// Entries are replayed with the credentials of the client that replays them,
+// so clients that act for different tenants need separate outboxes.
+type Outbox struct {
+	path      string
+	mu        sync.Mutex
+	file      *os.File
+	pending   []OutboxEntry
+	lastID    uint64
+	replaying bool
+}
+
+// OpenOutbox opens the outbox file at path, creating it if needed, and loads
+// the entries that have not been replayed yet.
+func OpenOutbox(path string) (*Outbox, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open outbox: %w", err)
+	}
+
+	o := &Outbox{path: path, file: file}
+	if err := o.load(); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return o, nil
+}
+
+// load reads the outbox file. A malformed last line, left by a crash during a
+// write, is ignored; malformed lines elsewhere are an error.
+func (o *Outbox) load() error {
+	data, err := os.ReadFile(o.path)
+	if err != nil {
+		return fmt.Errorf("failed to read outbox: %w", err)
+	}
+
+	lines := bytes.Split(data, []byte("\n"))
+	for i, line := range lines {
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+
+		var record outboxRecord
+		if err := json.Unmarshal(line, &record); err != nil {
+			if i == len(lines)-1 {
+				break
+			}
+			return fmt.Errorf("failed to read outbox %s, line %d: %w", o.path, i+1, err)
+		}
+
+		switch {
+		case record.Entry != nil:
+			o.pending = append(o.pending, *record.Entry)
+			o.lastID = max(o.lastID, record.Entry.ID)
+		case record.Done != nil:
+			o.pending = slices.DeleteFunc(o.pending, func(e OutboxEntry) bool { return e.ID == record.Done.ID })
+		}
+	}
+
+	// Start the next write on a fresh line after a truncated one.
+	if len(data) > 0 && data[len(data)-1] != '\n' {
+		if _, err := o.file.Write([]byte("\n")); err != nil {
+			return fmt.Errorf("failed to repair outbox: %w", err)
+		}
+	}
+	return nil
+}
+
+// Pending returns the entries that have not been replayed yet, oldest first.
+func (o *Outbox) Pending() []OutboxEntry {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	return slices.Clone(o.pending)
+}
+
+// hasPending reports whether an update, replace or delete of the resource at
+// path is pending.
+func (o *Outbox) hasPending(path string) bool {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	return slices.ContainsFunc(o.pending, func(e OutboxEntry) bool {
+		return e.Method != http.MethodPost && e.Path() == path
+	})
+}
+
+// startReplay marks the outbox as being replayed. It returns false if a replay
+// is already in progress.
+func (o *Outbox) startReplay() bool {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.replaying {
+		return false
+	}
+	o.replaying = true
+	return true
+}
+
+// endReplay marks the end of a replay started with startReplay.
+func (o *Outbox) endReplay() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.replaying = false
+}
+
+// Close closes the outbox file.
+func (o *Outbox) Close() error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	return o.file.Close()
+}
+
+// Compact rewrites the outbox file with only the pending entries, dropping
+// the records of replayed ones. The file is replaced atomically.
+func (o *Outbox) Compact() error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	tmp, err := os.CreateTemp(filepath.Dir(o.path), filepath.Base(o.path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to compact outbox: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	writer := bufio.NewWriter(tmp)
+	for i := range o.pending {
+		if err := writeRecord(writer, outboxRecord{Entry: &o.pending[i]}); err != nil {
+			tmp.Close()
+			return fmt.Errorf("failed to compact outbox: %w", err)
+		}
+	}
+	if err := errors.Join(writer.Flush(), tmp.Sync(), tmp.Close()); err != nil {
+		return fmt.Errorf("failed to compact outbox: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), o.path); err != nil {
+		return fmt.Errorf("failed to compact outbox: %w", err)
+	}
+
+	file, err := os.OpenFile(o.path, os.O_RDWR|os.O_APPEND, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to reopen outbox: %w", err)
+	}
+	o.file.Close()
+	o.file = file
+	return nil
+}
+
+// add appends a new entry and returns it with its ID assigned.
+func (o *Outbox) add(entry OutboxEntry) (OutboxEntry, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	entry.ID = o.lastID + 1
+	if err := o.append(outboxRecord{Entry: &entry}); err != nil {
+		return OutboxEntry{}, err
+	}
+	o.lastID = entry.ID
+	o.pending = append(o.pending, entry)
+	return entry, nil
+}
+
+// complete records the outcome of replaying an entry and removes it from the
+// pending entries.
+func (o *Outbox) complete(id uint64, status ReplayStatus) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if err := o.append(outboxRecord{Done: &outboxDone{ID: id, Status: status}}); err != nil {
+		return err
+	}
+	o.pending = slices.DeleteFunc(o.pending, func(e OutboxEntry) bool { return e.ID == id })
+	return nil
+}
+
+// append writes a record and syncs it to disk. It must be called with o.mu held.
+func (o *Outbox) append(record outboxRecord) error {
+	var buf bytes.Buffer
+	if err := writeRecord(&buf, record); err != nil {
+		return err
+	}
+	if _, err := o.file.Write(buf.Bytes()); err != nil {
+		return fmt.Errorf("failed to write outbox: %w", err)
+	}
+	if err := o.file.Sync(); err != nil {
+		return fmt.Errorf("failed to write outbox: %w", err)
+	}
+	return nil
+}
+
+// writeRecord writes record as a single JSON line.
+func writeRecord(w interface{ Write([]byte) (int, error) }, record outboxRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to encode outbox record: %w", err)
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+type outboxReplayKey struct{}
+
+// unreachable reports whether a call failed without reaching the API, as
+// opposed to being rejected by it, abandoned by the caller or failing on the
+// client's side, which replaying it would not fix.
+func unreachable(ctx context.Context, err error) bool {
+	if err == nil || callerGaveUp(ctx) {
+		return false
+	}
+	if errors.Is(err, ErrOutboxPending) {
+		return true
+	}
+	switch errorClass(ctx, nil, err) {
+	case ErrorClassNetwork, ErrorClassTimeout, ErrorClassCircuitOpen:
+		return true
+	default:
+		return false
+	}
+}
+
+// behindOutbox reports whether req updates, replaces or deletes a resource
+// that has pending outbox entries, in which case it must be queued behind them
+// rather than sent.
+func (c *Client) behindOutbox(req *http.Request) bool {
+	call := transport.FromContext(req.Context())
+	if c.outbox == nil || call == nil || call.ResourceID == "" || req.Method == http.MethodGet ||
+		req.Method == http.MethodPost || req.Context().Value(outboxReplayKey{}) != nil {
+		return false
+	}
+	return c.outbox.hasPending(call.Path())
+}
+
+// enqueue stores a mutation that failed with err in the outbox and returns
+// the error to report to the caller.
+func (c *Client) enqueue(ctx context.Context, req *http.Request, err error) error {
+	call := transport.FromContext(ctx)
+	if c.outbox == nil || call == nil || req.Method == http.MethodGet || ctx.Value(outboxReplayKey{}) != nil ||
+		!unreachable(ctx, err) {
+		return err
+	}
+
+	body, readErr := readBody(req)
+	if readErr != nil || (len(body) > 0 && !json.Valid(body)) {
+		return err
+	}
+
+	entry, addErr := c.outbox.add(OutboxEntry{
+		Queued:         time.Now().UTC(),
+		Operation:      call.Operation,
+		Method:         req.Method,
+		Route:          call.Route,
+		ResourceID:     call.ResourceID,
+		ParentID:       call.ParentID,
+		Body:           body,
+		IdempotencyKey: req.Header.Get(IdempotencyKeyHeader),
+		IfMatch:        req.Header.Get("If-Match"),
+		Error:          err.Error(),
+	})
+	if addErr != nil {
+		return errors.Join(err, addErr)
+	}
+	return &QueuedError{ID: entry.ID, Err: err}
+}
+
+// ConflictPolicy decides what ReplayOutbox does when the API rejects an entry
+// because the resource changed or no longer exists (409 Conflict, 404 Not
+// Found or 412 Precondition Failed).
+type ConflictPolicy int
+
+const (
+	// ConflictSkip drops the entry and continues with the next one.
+	ConflictSkip ConflictPolicy = iota
+	// ConflictOverwrite sends an entry rejected with 412 Precondition Failed
+	// again without If-Match, so that it replaces the current state. Other
+	// conflicts are skipped.
+	ConflictOverwrite
+	// ConflictFail stops the replay. The entry and those after it stay pending.
+	ConflictFail
+)
+
+// ReplayStatus is the outcome of replaying an outbox entry.
+type ReplayStatus string
+
+// Replay statuses.
+const (
+	// ReplayApplied means the API accepted the entry.
+	ReplayApplied ReplayStatus = "applied"
+	// ReplayOverwritten means the entry was applied without its If-Match
+	// condition under ConflictOverwrite.
+	ReplayOverwritten ReplayStatus = "overwritten"
+	// ReplaySkipped means the entry conflicted and was dropped.
+	ReplaySkipped ReplayStatus = "skipped"
+	// ReplayFailed means the API rejected the entry for another reason, such
+	// as a validation error. It is dropped, since sending it again would fail
+	// the same way.
+	ReplayFailed ReplayStatus = "failed"
+	// ReplayPending means the entry was not replayed and stays in the outbox.
+	ReplayPending ReplayStatus = "pending"
+)
+
+// ReplayResult reports the outcome of replaying an outbox entry.
+type ReplayResult struct {
+	Entry  OutboxEntry
+	Status ReplayStatus
+	// StatusCode is the status code of the final response, or zero if none
+	// was received.
+	StatusCode int
+	// Err is the error the entry was rejected or stopped with, if any.
+	Err error
+}
+
+// ReplayOutbox sends the pending outbox entries in the order they were queued,
+// with their original bodies, idempotency keys and If-Match conditions, and
+// returns the outcome of each. Conflicts are handled according to policy.
+//
+// Replay stops at the first entry that cannot reach the API, that fails with
+// a server error, or that conflicts under ConflictFail; that entry and the
+// ones after it are reported as ReplayPending and the error is returned.
+// Entries that fail during replay are never queued a second time.
+//
+// Only one replay of an outbox runs at a time; a call made while another is
+// in progress returns ErrReplayInProgress.
+func (c *Client) ReplayOutbox(ctx context.Context, policy ConflictPolicy) ([]ReplayResult, error) {
+	if c.outbox == nil {
+		return nil, nil
+	}
+	if !c.outbox.startReplay() {
+		return nil, ErrReplayInProgress
+	}
+	defer c.outbox.endReplay()
+
+	entries := c.outbox.Pending()
+	results := make([]ReplayResult, 0, len(entries))
+	for i, entry := range entries {
+		result := c.replay(ctx, entry, policy)
+		results = append(results, result)
+		if result.Status == ReplayPending {
+			for _, rest := range entries[i+1:] {
+				results = append(results, ReplayResult{Entry: rest, Status: ReplayPending})
+			}
+			return results, fmt.Errorf("outbox entry %d: %w", entry.ID, result.Err)
+		}
+
+		if err := c.outbox.complete(entry.ID, result.Status); err != nil {
+			return results, err
+		}
+	}
+	return results, nil
+}
+
+// replay sends a single entry and classifies the outcome.
+func (c *Client) replay(ctx context.Context, entry OutboxEntry, policy ConflictPolicy) ReplayResult {
+	statusCode, err := c.sendEntry(ctx, entry, entry.IfMatch)
+	var precondition *PreconditionFailedError
+	if errors.As(err, &precondition) {
+		statusCode, err = http.StatusPreconditionFailed, nil
+	}
+	overwritten := statusCode == http.StatusPreconditionFailed && policy == ConflictOverwrite
+	if overwritten {
+		statusCode, err = c.sendEntry(ctx, entry, "")
+	}
+
+	result := ReplayResult{Entry: entry, StatusCode: statusCode, Err: err}
+	if err == nil && statusCode >= http.StatusBadRequest {
+		result.Err = &Error{StatusCode: statusCode}
+	}
+
+	var unsupported *UnsupportedError
+	switch {
+	case errors.As(err, &unsupported):
+		result.Status = ReplayFailed
+	case err != nil, statusCode == http.StatusTooManyRequests, statusCode >= http.StatusInternalServerError:
+		result.Status = ReplayPending
+	case isConflict(statusCode) && policy == ConflictFail:
+		result.Status = ReplayPending
+	case isConflict(statusCode):
+		result.Status = ReplaySkipped
+	case statusCode >= http.StatusBadRequest:
+		result.Status = ReplayFailed
+	case overwritten:
+		result.Status = ReplayOverwritten
+	default:
+		result.Status = ReplayApplied
+	}
+	return result
+}
+
+// isConflict reports whether statusCode means the resource changed or no
+// longer exists since the entry was queued.
+func isConflict(statusCode int) bool {
+	switch statusCode {
+	case http.StatusConflict, http.StatusNotFound, http.StatusPreconditionFailed:
+		return true
+	default:
+		return false
+	}
+}
+
+// sendEntry sends an entry with the given If-Match condition and returns the
+// response status code.
+func (c *Client) sendEntry(ctx context.Context, entry OutboxEntry, ifMatch string) (int, error) {
+	call := &transport.Call{
+		Operation:  entry.Operation,
+		Route:      entry.Route,
+		ResourceID: entry.ResourceID,
+		ParentID:   entry.ParentID,
+	}
+	ctx = transport.NewContext(context.WithValue(ctx, outboxReplayKey{}, entry.ID), call)
+	ctx = WithIfMatch(WithIdempotencyKey(ctx, entry.IdempotencyKey), ifMatch)
+
+	url := strings.TrimSuffix(c.baseURL, "/") + entry.Path()
+	req, err := http.NewRequestWithContext(ctx, entry.Method, url, bytes.NewReader(entry.Body))
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if len(entry.Body) > 0 {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return 0, err
+	}
+	drain(resp)
+	return resp.StatusCode, nil
+}
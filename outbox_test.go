@@ -0,0 +1,357 @@
+package tama_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	tama "github.com/upmaru/tama-go"
+	"github.com/upmaru/tama-go/memory"
+	"github.com/upmaru/tama-go/neural"
+)
+
+// outboxServer drops every connection while offline and otherwise records the
+// requests it receives. Updates conditional on the ETag "stale" fail with 412.
+type outboxServer struct {
+	offline  atomic.Bool
+	mu       sync.Mutex
+	requests []string
+}
+
+func (s *outboxServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if s.offline.Load() {
+		conn, _, _ := w.(http.Hijacker).Hijack()
+		conn.Close()
+		return
+	}
+
+	s.mu.Lock()
+	s.requests = append(s.requests, strings.Join(strings.Fields(
+		r.Method+" "+r.URL.Path+" "+r.Header.Get(tama.IdempotencyKeyHeader)+" "+r.Header.Get("If-Match")), " "))
+	s.mu.Unlock()
+
+	if r.Header.Get("If-Match") == `"stale"` {
+		w.WriteHeader(http.StatusPreconditionFailed)
+		return
+	}
+	if r.Method == http.MethodDelete {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write([]byte(`{"data":{"id":"resource-123"}}`))
+}
+
+func (s *outboxServer) received() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]string(nil), s.requests...)
+}
+
+func openOutbox(t *testing.T, path string) *tama.Outbox {
+	t.Helper()
+	outbox, err := tama.OpenOutbox(path)
+	if err != nil {
+		t.Fatalf("Failed to open outbox: %v", err)
+	}
+	t.Cleanup(func() { outbox.Close() })
+	return outbox
+}
+
+func TestOutboxQueuesOfflineMutations(t *testing.T) {
+	srv := &outboxServer{}
+	srv.offline.Store(true)
+	server := createMockServer(t, srv.ServeHTTP)
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "outbox.jsonl")
+	client := tama.NewClient(tama.Config{BaseURL: server.URL, APIKey: "test-key", Outbox: openOutbox(t, path)})
+
+	ctx := tama.WithIdempotencyKey(context.Background(), "create-tone")
+	_, err := client.Memory.CreatePromptWithContext(ctx, "space-123", memory.CreatePromptRequest{
+		Prompt: memory.PromptRequestData{Name: "Tone", Content: "Be brief.", Role: "system"},
+	})
+	var queued *tama.QueuedError
+	if !errors.As(err, &queued) || queued.ID != 1 {
+		t.Fatalf("Expected the create to be queued, got %v", err)
+	}
+	if err := client.Neural.DeleteSpace("space-123"); !errors.As(err, &queued) || queued.ID != 2 {
+		t.Fatalf("Expected the delete to be queued, got %v", err)
+	}
+	if _, err := client.Neural.GetSpace("space-123"); err == nil || errors.As(err, &queued) {
+		t.Fatalf("Expected reads to fail without being queued, got %v", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Errorf("Expected the outbox to be private, got %v", info.Mode().Perm())
+	}
+
+	// The entries survive a restart.
+	reopened := openOutbox(t, path)
+	pending := reopened.Pending()
+	if len(pending) != 2 {
+		t.Fatalf("Expected 2 pending entries, got %d", len(pending))
+	}
+	create := pending[0]
+	if create.Operation != "memory.CreatePrompt" || create.Method != http.MethodPost ||
+		create.Path() != "/provision/memory/spaces/space-123/prompts" || create.IdempotencyKey != "create-tone" ||
+		!strings.Contains(string(create.Body), `"content":"Be brief."`) || create.Error == "" {
+		t.Errorf("Unexpected entry: %+v", create)
+	}
+
+	// Replaying while still offline leaves everything pending.
+	client = tama.NewClient(tama.Config{BaseURL: server.URL, APIKey: "test-key", Outbox: reopened})
+	results, err := client.ReplayOutbox(context.Background(), tama.ConflictSkip)
+	if err == nil || len(results) != 2 || results[0].Status != tama.ReplayPending ||
+		results[1].Status != tama.ReplayPending {
+		t.Fatalf("Expected the replay to stop, got %+v, %v", results, err)
+	}
+	if len(reopened.Pending()) != 2 {
+		t.Fatalf("Expected no entry to be dropped or queued again, got %d", len(reopened.Pending()))
+	}
+
+	srv.offline.Store(false)
+	results, err = client.ReplayOutbox(context.Background(), tama.ConflictSkip)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(results) != 2 || results[0].Status != tama.ReplayApplied || results[1].Status != tama.ReplayApplied ||
+		results[1].StatusCode != http.StatusNoContent {
+		t.Errorf("Unexpected results: %+v", results)
+	}
+	want := []string{
+		"POST /provision/memory/spaces/space-123/prompts create-tone",
+		"DELETE /provision/neural/spaces/space-123",
+	}
+	if got := srv.received(); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Expected the entries in order, got %q", got)
+	}
+
+	if len(openOutbox(t, path).Pending()) != 0 {
+		t.Error("Expected replayed entries to stay replayed after a restart")
+	}
+	if err := reopened.Compact(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if data, _ := os.ReadFile(path); len(data) != 0 {
+		t.Errorf("Expected an empty outbox after compacting, got %s", data)
+	}
+}
+
+func TestOutboxConflictPolicies(t *testing.T) {
+	tests := []struct {
+		policy   tama.ConflictPolicy
+		statuses []tama.ReplayStatus
+		requests []string
+		pending  int
+	}{
+		{
+			policy:   tama.ConflictSkip,
+			statuses: []tama.ReplayStatus{tama.ReplaySkipped, tama.ReplayApplied},
+			requests: []string{
+				`PATCH /provision/neural/spaces/space-1 "stale"`,
+				"PATCH /provision/neural/spaces/space-2",
+			},
+		},
+		{
+			policy:   tama.ConflictOverwrite,
+			statuses: []tama.ReplayStatus{tama.ReplayOverwritten, tama.ReplayApplied},
+			requests: []string{
+				`PATCH /provision/neural/spaces/space-1 "stale"`,
+				"PATCH /provision/neural/spaces/space-1",
+				"PATCH /provision/neural/spaces/space-2",
+			},
+		},
+		{
+			policy:   tama.ConflictFail,
+			statuses: []tama.ReplayStatus{tama.ReplayPending, tama.ReplayPending},
+			requests: []string{`PATCH /provision/neural/spaces/space-1 "stale"`},
+			pending:  2,
+		},
+	}
+
+	for _, tt := range tests {
+		srv := &outboxServer{}
+		srv.offline.Store(true)
+		server := createMockServer(t, srv.ServeHTTP)
+		defer server.Close()
+
+		outbox := openOutbox(t, filepath.Join(t.TempDir(), "outbox.jsonl"))
+		client := tama.NewClient(tama.Config{BaseURL: server.URL, APIKey: "test-key", Outbox: outbox})
+		update := neural.UpdateSpaceRequest{Space: neural.UpdateSpaceData{Name: "Renamed"}}
+		ctx := tama.WithIfMatch(context.Background(), `"stale"`)
+		if _, err := client.Neural.UpdateSpaceWithContext(ctx, "space-1", update); err == nil {
+			t.Fatal("Expected error, got nil")
+		}
+		if _, err := client.Neural.UpdateSpace("space-2", update); err == nil {
+			t.Fatal("Expected error, got nil")
+		}
+
+		srv.offline.Store(false)
+		results, err := client.ReplayOutbox(context.Background(), tt.policy)
+		if (err != nil) != (tt.pending > 0) {
+			t.Errorf("Policy %d: unexpected error: %v", tt.policy, err)
+		}
+		for i, result := range results {
+			if i >= len(tt.statuses) || result.Status != tt.statuses[i] {
+				t.Errorf("Policy %d: unexpected result %d: %+v", tt.policy, i, result)
+			}
+		}
+		if got := srv.received(); strings.Join(got, "\n") != strings.Join(tt.requests, "\n") {
+			t.Errorf("Policy %d: unexpected requests %q", tt.policy, got)
+		}
+		if len(outbox.Pending()) != tt.pending {
+			t.Errorf("Policy %d: expected %d pending entries, got %d", tt.policy, tt.pending, len(outbox.Pending()))
+		}
+	}
+}
+
+func TestOutboxKeepsResourceOrder(t *testing.T) {
+	srv := &outboxServer{}
+	srv.offline.Store(true)
+	server := createMockServer(t, srv.ServeHTTP)
+	defer server.Close()
+
+	outbox := openOutbox(t, filepath.Join(t.TempDir(), "outbox.jsonl"))
+	client := tama.NewClient(tama.Config{BaseURL: server.URL, APIKey: "test-key", Outbox: outbox})
+	update := func(id, name string) error {
+		_, err := client.Neural.UpdateSpace(id, neural.UpdateSpaceRequest{Space: neural.UpdateSpaceData{Name: name}})
+		return err
+	}
+
+	var queued *tama.QueuedError
+	if err := update("space-1", "Old"); !errors.As(err, &queued) {
+		t.Fatalf("Expected the update to be queued, got %v", err)
+	}
+
+	// Back online, a newer update of the same space waits behind the old one.
+	srv.offline.Store(false)
+	if err := update("space-1", "New"); !errors.As(err, &queued) || !errors.Is(err, tama.ErrOutboxPending) {
+		t.Fatalf("Expected the update to be queued behind the pending one, got %v", err)
+	}
+	if err := update("space-2", "Other"); err != nil {
+		t.Fatalf("Expected updates of other spaces to be sent, got %v", err)
+	}
+
+	if _, err := client.ReplayOutbox(context.Background(), tama.ConflictSkip); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	want := []string{
+		"PATCH /provision/neural/spaces/space-2",
+		"PATCH /provision/neural/spaces/space-1",
+		"PATCH /provision/neural/spaces/space-1",
+	}
+	if got := srv.received(); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Unexpected requests %q", got)
+	}
+	if pending := outbox.Pending(); len(pending) != 0 {
+		t.Errorf("Expected no pending entries, got %d", len(pending))
+	}
+}
+
+func TestOutboxReplayIsExclusive(t *testing.T) {
+	srv := &outboxServer{}
+	srv.offline.Store(true)
+	release := make(chan struct{})
+	started := make(chan struct{}, 1)
+	server := createMockServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if !srv.offline.Load() {
+			started <- struct{}{}
+			<-release
+		}
+		srv.ServeHTTP(w, r)
+	})
+	defer server.Close()
+
+	client := tama.NewClient(tama.Config{
+		BaseURL: server.URL,
+		APIKey:  "test-key",
+		Outbox:  openOutbox(t, filepath.Join(t.TempDir(), "outbox.jsonl")),
+	})
+	if err := client.Neural.DeleteSpace("space-1"); err == nil {
+		t.Fatal("Expected error, got nil")
+	}
+
+	srv.offline.Store(false)
+	done := make(chan error)
+	go func() {
+		_, err := client.ReplayOutbox(context.Background(), tama.ConflictSkip)
+		done <- err
+	}()
+	<-started
+
+	_, err := client.ReplayOutbox(context.Background(), tama.ConflictSkip)
+	if !errors.Is(err, tama.ErrReplayInProgress) {
+		t.Errorf("Expected ErrReplayInProgress, got %v", err)
+	}
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got := srv.received(); len(got) != 1 {
+		t.Errorf("Expected the entry to be sent once, got %q", got)
+	}
+}
+
+func TestOutboxSkipsClientSideErrors(t *testing.T) {
+	srv := &outboxServer{}
+	server := createMockServer(t, srv.ServeHTTP)
+	defer server.Close()
+
+	outbox := openOutbox(t, filepath.Join(t.TempDir(), "outbox.jsonl"))
+	metrics := tama.NewExpvarMetrics()
+	client := tama.NewClient(tama.Config{
+		BaseURL:     server.URL,
+		Credentials: tama.EnvCredential("TAMA_TEST_UNSET_KEY"),
+		Outbox:      outbox,
+		Metrics:     metrics,
+	})
+
+	_, err := client.Memory.UpdatePrompt("prompt-1", memory.UpdatePromptRequest{
+		Prompt: memory.UpdatePromptData{Content: "Be brief."},
+	})
+	var queued *tama.QueuedError
+	if !errors.Is(err, tama.ErrNoCredential) || errors.As(err, &queued) {
+		t.Fatalf("Expected the provider error to be returned as is, got %v", err)
+	}
+	if len(outbox.Pending()) != 0 || len(srv.received()) != 0 {
+		t.Errorf("Expected nothing to be queued or sent, got %d entries and %d requests",
+			len(outbox.Pending()), len(srv.received()))
+	}
+	if !strings.Contains(metrics.String(), `"memory.prompt.update.`+tama.ErrorClassCredential+`": 1`) {
+		t.Errorf("Expected a credential error to be counted, got %s", metrics.String())
+	}
+}
+
+func TestOutboxTruncatedRecord(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "outbox.jsonl")
+	entry := `{"entry":{"id":1,"operation":"neural.DeleteSpace","method":"DELETE",` +
+		`"route":"/provision/neural/spaces/{id}","resource_id":"space-1","error":"EOF"}}`
+	if err := os.WriteFile(path, []byte(entry+"\n"+`{"entry":{"id":2,"oper`), 0o600); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	outbox := openOutbox(t, path)
+	if pending := outbox.Pending(); len(pending) != 1 || pending[0].Path() != "/provision/neural/spaces/space-1" {
+		t.Fatalf("Expected the complete entry to be loaded, got %+v", pending)
+	}
+	outbox.Close()
+
+	if err := os.WriteFile(path, []byte("not json\n"+entry+"\n"), 0o600); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := tama.OpenOutbox(path); err == nil {
+		t.Error("Expected a corrupt outbox to be rejected")
+	}
+}
@@ -0,0 +1,294 @@
+package tama
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Environment variables read by LoadConfig.
+const (
+	// EnvBaseURL sets the API base URL.
+	EnvBaseURL = "TAMA_BASE_URL"
+	// EnvAPIKey sets the API key.
+	EnvAPIKey = "TAMA_API_KEY"
+	// EnvAPIKeyFile names a file that holds the API key.
+	EnvAPIKeyFile = "TAMA_API_KEY_FILE"
+	// EnvTimeout sets the request timeout as a Go duration, e.g. "45s".
+	EnvTimeout = "TAMA_TIMEOUT"
+	// EnvProfile selects a profile from the profiles file.
+	EnvProfile = "TAMA_PROFILE"
+	// EnvConfigFile overrides the location of the profiles file.
+	EnvConfigFile = "TAMA_CONFIG_FILE"
+)
+
+// DefaultProfile is the profile used when none is selected.
+const DefaultProfile = "default"
+
+var (
+	// ErrNoBaseURL is returned by LoadConfig when no base URL is configured.
+	ErrNoBaseURL = errors.New("no base URL configured")
+	// ErrNoAPIKey is returned by LoadConfig when no API key is configured.
+	ErrNoAPIKey = errors.New("no API key configured")
+	// ErrProfileNotFound is returned by LoadConfig when the selected profile does not exist.
+	ErrProfileNotFound = errors.New("profile not found")
+)
+
+// ConfigError describes an invalid setting found by LoadConfig.
+type ConfigError struct {
+	// Source is where the setting came from, e.g. "TAMA_TIMEOUT" or
+	// "/home/me/.config/tama/config.yaml: profile staging".
+	Source string
+	Err    error
+}
+
+func (e *ConfigError) Error() string {
+	return fmt.Sprintf("invalid configuration in %s: %v", e.Source, e.Err)
+}
+
+func (e *ConfigError) Unwrap() error {
+	return e.Err
+}
+
+// Profile is a named set of settings in the profiles file.
+type Profile struct {
+	BaseURL    string `yaml:"base_url"`
+	APIKey     string `yaml:"api_key"`
+	APIKeyFile string `yaml:"api_key_file"`
+	Timeout    string `yaml:"timeout"`
+}
+
+// profilesFile is the layout of the profiles file:
+//
+//	default_profile: production
+//	profiles:
+//	  production:
+//	    base_url: https://api.tama.io
+//	    api_key_file: ~/.config/tama/production.key
+//	    timeout: 30s
+type profilesFile struct {
+	DefaultProfile string             `yaml:"default_profile"`
+	Profiles       map[string]Profile `yaml:"profiles"`
+}
+
+// DefaultConfigFile returns the default location of the profiles file,
+// $XDG_CONFIG_HOME/tama/config.yaml or ~/.config/tama/config.yaml.
+func DefaultConfigFile() (string, error) {
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, "tama", "config.yaml"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".config", "tama", "config.yaml"), nil
+}
+
+// LoadConfig builds a Config from the environment and the profiles file.
+//
+// The profile is the one named by the profile argument, or else by
+// TAMA_PROFILE, or else by default_profile in the file, or else "default".
+// The profiles file is read from TAMA_CONFIG_FILE, or else from
+// DefaultConfigFile; a missing file is only an error if TAMA_CONFIG_FILE or a
+// profile was given explicitly.
+//
+// Each setting is taken from the first of these that sets it:
+//
+//  1. Environment variables: TAMA_BASE_URL, TAMA_API_KEY or
+//     TAMA_API_KEY_FILE, TAMA_TIMEOUT.
+//  2. The selected profile: base_url, api_key or api_key_file, timeout.
+//  3. Defaults: DefaultTimeout. There is no default base URL or API key.
+//
+// A key file is read on every request, so keys can be rotated by replacing it.
+func LoadConfig(profile string) (Config, error) {
+	explicit := profile != ""
+	if profile == "" {
+		profile = os.Getenv(EnvProfile)
+		explicit = profile != ""
+	}
+
+	settings, source, err := loadProfile(profile, explicit)
+	if err != nil {
+		return Config{}, err
+	}
+
+	env := Profile{
+		BaseURL:    os.Getenv(EnvBaseURL),
+		APIKey:     os.Getenv(EnvAPIKey),
+		APIKeyFile: os.Getenv(EnvAPIKeyFile),
+		Timeout:    os.Getenv(EnvTimeout),
+	}
+	if err := env.validate("environment"); err != nil {
+		return Config{}, err
+	}
+
+	var config Config
+	if config.BaseURL, err = baseURLSetting(env, settings, source); err != nil {
+		return Config{}, err
+	}
+	if config.Timeout, err = timeoutSetting(env, settings, source); err != nil {
+		return Config{}, err
+	}
+	if err := keySetting(&config, env, settings, source); err != nil {
+		return Config{}, err
+	}
+	return config, nil
+}
+
+// NewClientFromEnv creates a client from the configuration returned by
+// LoadConfig for the profile selected by TAMA_PROFILE.
+func NewClientFromEnv() (*Client, error) {
+	config, err := LoadConfig("")
+	if err != nil {
+		return nil, err
+	}
+	return NewClient(config), nil
+}
+
+// loadProfile reads the named profile from the profiles file. It returns the
+// profile and a description of where it came from.
+func loadProfile(name string, explicit bool) (Profile, string, error) {
+	path := os.Getenv(EnvConfigFile)
+	required := explicit || path != ""
+	if path == "" {
+		var err error
+		if path, err = DefaultConfigFile(); err != nil {
+			if required {
+				return Profile{}, "", fmt.Errorf("failed to locate profiles file: %w", err)
+			}
+			return Profile{}, "", nil
+		}
+	}
+
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) && !required {
+		return Profile{}, "", nil
+	}
+	if err != nil {
+		return Profile{}, "", fmt.Errorf("failed to read profiles file: %w", err)
+	}
+	defer file.Close()
+
+	var profiles profilesFile
+	decoder := yaml.NewDecoder(file)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&profiles); err != nil && !errors.Is(err, io.EOF) {
+		return Profile{}, "", &ConfigError{Source: path, Err: err}
+	}
+
+	if name == "" {
+		name = profiles.DefaultProfile
+		explicit = name != ""
+	}
+	if name == "" {
+		name = DefaultProfile
+	}
+
+	source := fmt.Sprintf("%s: profile %s", path, name)
+	profile, ok := profiles.Profiles[name]
+	if !ok {
+		if explicit {
+			err := fmt.Errorf("%w: %s", ErrProfileNotFound, name)
+			return Profile{}, "", &ConfigError{Source: path, Err: err}
+		}
+		return Profile{}, "", nil
+	}
+	if err := profile.validate(source); err != nil {
+		return Profile{}, "", err
+	}
+	return profile, source, nil
+}
+
+// validate checks settings that conflict within a single source.
+func (p Profile) validate(source string) error {
+	if p.APIKey != "" && p.APIKeyFile != "" {
+		return &ConfigError{Source: source, Err: errors.New("set either an API key or an API key file, not both")}
+	}
+	return nil
+}
+
+// baseURLSetting returns the base URL, preferring the environment over the profile.
+func baseURLSetting(env, profile Profile, source string) (string, error) {
+	value := profile.BaseURL
+	if env.BaseURL != "" {
+		value, source = env.BaseURL, EnvBaseURL
+	}
+	if value == "" {
+		return "", ErrNoBaseURL
+	}
+	if err := validateBaseURL(value); err != nil {
+		return "", &ConfigError{Source: source, Err: err}
+	}
+	return value, nil
+}
+
+// timeoutSetting returns the timeout, preferring the environment over the profile.
+func timeoutSetting(env, profile Profile, source string) (time.Duration, error) {
+	value := profile.Timeout
+	if env.Timeout != "" {
+		value, source = env.Timeout, EnvTimeout
+	}
+	if value == "" {
+		return DefaultTimeout, nil
+	}
+
+	timeout, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, &ConfigError{Source: source, Err: err}
+	}
+	if timeout <= 0 {
+		return 0, &ConfigError{Source: source, Err: fmt.Errorf("timeout %s must be positive", value)}
+	}
+	return timeout, nil
+}
+
+// keySetting sets the API key or key file on config, preferring the
+// environment over the profile.
+func keySetting(config *Config, env, profile Profile, source string) error {
+	key, file := profile.APIKey, profile.APIKeyFile
+	switch {
+	case env.APIKey != "":
+		key, file = env.APIKey, ""
+	case env.APIKeyFile != "":
+		key, file, source = "", env.APIKeyFile, EnvAPIKeyFile
+	}
+
+	switch {
+	case key != "":
+		config.APIKey = strings.TrimSpace(key)
+		return nil
+	case file != "":
+		path, err := expandHome(file)
+		if err != nil {
+			return &ConfigError{Source: source, Err: err}
+		}
+		credential := NewFileCredential(path)
+		if _, err := credential.Credential(context.Background()); err != nil {
+			return &ConfigError{Source: source, Err: err}
+		}
+		config.Credentials = credential
+		return nil
+	default:
+		return ErrNoAPIKey
+	}
+}
+
+// expandHome replaces a leading "~/" in path with the user's home directory.
+func expandHome(path string) (string, error) {
+	rest, ok := strings.CutPrefix(path, "~/")
+	if !ok {
+		return path, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, rest), nil
+}
@@ -0,0 +1,241 @@
+package tama_test
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	tama "github.com/upmaru/tama-go"
+)
+
+// isolateEnv clears the TAMA_* variables and points the profiles file at an
+// empty directory. It returns the path of the default profiles file.
+func isolateEnv(t *testing.T) string {
+	t.Helper()
+	for _, name := range []string{
+		tama.EnvBaseURL, tama.EnvAPIKey, tama.EnvAPIKeyFile,
+		tama.EnvTimeout, tama.EnvProfile, tama.EnvConfigFile,
+	} {
+		t.Setenv(name, "")
+	}
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	return filepath.Join(dir, "tama", "config.yaml")
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+const testProfiles = `
+default_profile: staging
+profiles:
+  staging:
+    base_url: https://staging.tama.io
+    api_key: staging-key
+    timeout: 10s
+  production:
+    base_url: https://api.tama.io
+    api_key: production-key
+`
+
+func TestLoadConfigFromEnvironment(t *testing.T) {
+	isolateEnv(t)
+	t.Setenv(tama.EnvBaseURL, "https://api.tama.io")
+	t.Setenv(tama.EnvAPIKey, "env-key")
+	t.Setenv(tama.EnvTimeout, "45s")
+
+	config, err := tama.LoadConfig("")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if config.BaseURL != "https://api.tama.io" || config.APIKey != "env-key" || config.Timeout != 45*time.Second {
+		t.Errorf("Unexpected config: %+v", config)
+	}
+}
+
+func TestLoadConfigFromProfiles(t *testing.T) {
+	path := isolateEnv(t)
+	writeFile(t, path, testProfiles)
+
+	config, err := tama.LoadConfig("")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if config.BaseURL != "https://staging.tama.io" || config.APIKey != "staging-key" || config.Timeout != 10*time.Second {
+		t.Errorf("Expected default_profile staging, got %+v", config)
+	}
+
+	t.Setenv(tama.EnvProfile, "production")
+	config, err = tama.LoadConfig("")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if config.BaseURL != "https://api.tama.io" || config.Timeout != tama.DefaultTimeout {
+		t.Errorf("Expected TAMA_PROFILE to select production, got %+v", config)
+	}
+
+	// Environment variables override the profile; the argument overrides TAMA_PROFILE.
+	t.Setenv(tama.EnvAPIKey, "env-key")
+	config, err = tama.LoadConfig("staging")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if config.BaseURL != "https://staging.tama.io" || config.APIKey != "env-key" {
+		t.Errorf("Expected staging profile with env key, got %+v", config)
+	}
+}
+
+func TestLoadConfigKeyFile(t *testing.T) {
+	path := isolateEnv(t)
+	keyFile := filepath.Join(t.TempDir(), "key")
+	writeFile(t, keyFile, "file-key\n")
+	writeFile(t, path, "profiles:\n  default:\n    base_url: http://localhost:4000\n    api_key_file: "+keyFile+"\n")
+
+	var authorization string
+	server := createMockServer(t, func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"data":{"id":"space-123"}}`))
+	})
+	defer server.Close()
+	t.Setenv(tama.EnvBaseURL, server.URL)
+
+	client, err := tama.NewClientFromEnv()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := client.Neural.GetSpace("space-123"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if authorization != "Bearer file-key" {
+		t.Errorf("Expected key from file, got %q", authorization)
+	}
+}
+
+func TestLoadConfigErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		env     map[string]string
+		profile string
+		check   func(t *testing.T, err error)
+	}{
+		{
+			name: "missing base URL",
+			env:  map[string]string{tama.EnvAPIKey: "key"},
+			check: func(t *testing.T, err error) {
+				if !errors.Is(err, tama.ErrNoBaseURL) {
+					t.Errorf("Expected ErrNoBaseURL, got %v", err)
+				}
+			},
+		},
+		{
+			name: "missing API key",
+			env:  map[string]string{tama.EnvBaseURL: "https://api.tama.io"},
+			check: func(t *testing.T, err error) {
+				if !errors.Is(err, tama.ErrNoAPIKey) {
+					t.Errorf("Expected ErrNoAPIKey, got %v", err)
+				}
+			},
+		},
+		{
+			name:    "unknown profile",
+			file:    testProfiles,
+			profile: "qa",
+			check: func(t *testing.T, err error) {
+				if !errors.Is(err, tama.ErrProfileNotFound) {
+					t.Errorf("Expected ErrProfileNotFound, got %v", err)
+				}
+			},
+		},
+		{
+			name: "malformed timeout",
+			env:  map[string]string{tama.EnvBaseURL: "https://api.tama.io", tama.EnvAPIKey: "key", tama.EnvTimeout: "soon"},
+			check: func(t *testing.T, err error) {
+				var configErr *tama.ConfigError
+				if !errors.As(err, &configErr) || configErr.Source != tama.EnvTimeout {
+					t.Errorf("Expected ConfigError from TAMA_TIMEOUT, got %v", err)
+				}
+			},
+		},
+		{
+			name: "relative base URL",
+			file: "profiles:\n  default:\n    base_url: api.tama.io\n    api_key: key\n",
+			check: func(t *testing.T, err error) {
+				var configErr *tama.ConfigError
+				if !errors.As(err, &configErr) {
+					t.Errorf("Expected ConfigError, got %v", err)
+				}
+			},
+		},
+		{
+			name: "unknown field",
+			file: "profiles:\n  default:\n    base_uri: https://api.tama.io\n",
+			check: func(t *testing.T, err error) {
+				var configErr *tama.ConfigError
+				if !errors.As(err, &configErr) {
+					t.Errorf("Expected ConfigError, got %v", err)
+				}
+			},
+		},
+		{
+			name: "key and key file",
+			file: "profiles:\n  default:\n    base_url: https://api.tama.io\n    api_key: key\n    api_key_file: key.txt\n",
+			check: func(t *testing.T, err error) {
+				var configErr *tama.ConfigError
+				if !errors.As(err, &configErr) {
+					t.Errorf("Expected ConfigError, got %v", err)
+				}
+			},
+		},
+		{
+			name: "missing key file",
+			env: map[string]string{
+				tama.EnvBaseURL:    "https://api.tama.io",
+				tama.EnvAPIKeyFile: "/nonexistent/tama.key",
+			},
+			check: func(t *testing.T, err error) {
+				if !errors.Is(err, os.ErrNotExist) {
+					t.Errorf("Expected missing file error, got %v", err)
+				}
+			},
+		},
+		{
+			name: "missing explicit profiles file",
+			env:  map[string]string{tama.EnvConfigFile: "/nonexistent/config.yaml"},
+			check: func(t *testing.T, err error) {
+				if !errors.Is(err, os.ErrNotExist) {
+					t.Errorf("Expected missing file error, got %v", err)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := isolateEnv(t)
+			if tt.file != "" {
+				writeFile(t, path, tt.file)
+			}
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+
+			_, err := tama.LoadConfig(tt.profile)
+			if err == nil {
+				t.Fatal("Expected error, got nil")
+			}
+			tt.check(t, err)
+		})
+	}
+}
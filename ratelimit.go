@@ -0,0 +1,195 @@
+package tama
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RateLimit configures a client-side token bucket that spaces out requests
+// before they reach the API.
+type RateLimit struct {
+	// RequestsPerSecond is the sustained request rate. Zero disables the
+	// token bucket; server rate-limit headers are still honoured.
+	RequestsPerSecond float64
+	// Burst is the number of requests that may be sent back to back. Zero means 1.
+	Burst int
+}
+
+// RateLimitedError is returned when the API answers 429 Too Many Requests, or
+// when the client would have to wait for the rate limit to reset beyond the
+// call's deadline.
+type RateLimitedError struct {
+	// Local is true when the client refused the call without sending it
+	// because waiting for the rate limit would outlast the call's deadline.
+	// The API never saw the call.
+	Local bool
+	// ResetAt is when the API is expected to accept requests again. It is
+	// zero if the server did not say.
+	ResetAt time.Time
+	// Limit and Remaining mirror the X-RateLimit-Limit and
+	// X-RateLimit-Remaining headers, or -1 when they were absent.
+	Limit     int
+	Remaining int
+}
+
+func (e *RateLimitedError) Error() string {
+	if e.Local {
+		return "rate limited: request not sent, the next slot at " + e.ResetAt.Format(time.RFC3339) +
+			" is past the call's deadline"
+	}
+	if e.ResetAt.IsZero() {
+		return "rate limited by API"
+	}
+	return "rate limited by API until " + e.ResetAt.Format(time.RFC3339)
+}
+
+// throttleTransport is an http.RoundTripper that applies the client-side
+// token bucket and pauses requests when the API reports that its rate limit is
+// exhausted.
+type throttleTransport struct {
+	next        http.RoundTripper
+	rate        float64
+	burst       float64
+	mu          sync.Mutex
+	tokens      float64
+	last        time.Time
+	pausedUntil time.Time
+}
+
+// newThrottleTransport wraps next with the given rate limit. A nil limit only
+// honours the server's rate-limit headers.
+func newThrottleTransport(next http.RoundTripper, limit *RateLimit) *throttleTransport {
+	t := &throttleTransport{next: next}
+	if limit != nil && limit.RequestsPerSecond > 0 {
+		t.rate = limit.RequestsPerSecond
+		t.burst = float64(max(limit.Burst, 1))
+		t.tokens = t.burst
+	}
+	return t
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *throttleTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+
+	now := time.Now()
+	wait := t.reserve(now)
+	if wait > 0 {
+		if deadline, ok := ctx.Deadline(); ok && deadline.Before(now.Add(wait)) {
+			t.refund()
+			return nil, &RateLimitedError{Local: true, ResetAt: now.Add(wait), Limit: -1, Remaining: -1}
+		}
+		if err := sleep(ctx, wait); err != nil {
+			t.refund()
+			return nil, err
+		}
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	limited := t.observe(resp, time.Now())
+	if limited != nil {
+		drain(resp)
+		return nil, limited
+	}
+	return resp, nil
+}
+
+// reserve takes a token from the bucket and returns how long the caller has
+// to wait before sending its request.
+func (t *throttleTransport) reserve(now time.Time) time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var wait time.Duration
+	if t.pausedUntil.After(now) {
+		wait = t.pausedUntil.Sub(now)
+	}
+
+	if t.rate > 0 {
+		if !t.last.IsZero() {
+			t.tokens = min(t.burst, t.tokens+now.Sub(t.last).Seconds()*t.rate)
+		}
+		t.last = now
+		t.tokens--
+		if t.tokens < 0 {
+			wait = max(wait, time.Duration(-t.tokens/t.rate*float64(time.Second)))
+		}
+	}
+	return wait
+}
+
+// refund returns a token for a request that was never sent.
+func (t *throttleTransport) refund() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.rate > 0 {
+		t.tokens = min(t.burst, t.tokens+1)
+	}
+}
+
+// observe records the rate-limit headers of resp. It returns a
+// RateLimitedError if the response is a 429.
+func (t *throttleTransport) observe(resp *http.Response, now time.Time) *RateLimitedError {
+	limit := headerInt(resp.Header, "X-RateLimit-Limit")
+	remaining := headerInt(resp.Header, "X-RateLimit-Remaining")
+
+	var resetAt time.Time
+	if after, ok := retryAfter(resp.Header.Get("Retry-After")); ok {
+		resetAt = now.Add(after)
+	} else if remaining == 0 || resp.StatusCode == http.StatusTooManyRequests {
+		resetAt = rateLimitReset(resp.Header.Get("X-RateLimit-Reset"), now)
+	}
+
+	if !resetAt.IsZero() && (remaining == 0 || resp.StatusCode == http.StatusTooManyRequests ||
+		resp.StatusCode == http.StatusServiceUnavailable) {
+		t.pause(resetAt)
+	}
+
+	if resp.StatusCode != http.StatusTooManyRequests {
+		return nil
+	}
+	return &RateLimitedError{ResetAt: resetAt, Limit: limit, Remaining: remaining}
+}
+
+// pause holds back new requests until the given time.
+func (t *throttleTransport) pause(until time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if until.After(t.pausedUntil) {
+		t.pausedUntil = until
+	}
+}
+
+// headerInt parses an integer header, returning -1 when it is absent or malformed.
+func headerInt(header http.Header, name string) int {
+	value, err := strconv.Atoi(header.Get(name))
+	if err != nil {
+		return -1
+	}
+	return value
+}
+
+// unixTimestampThreshold separates X-RateLimit-Reset values given as Unix
+// timestamps from values given as seconds until the reset.
+const unixTimestampThreshold = 1_000_000_000
+
+// rateLimitReset parses an X-RateLimit-Reset header given either as a Unix
+// timestamp or as a number of seconds from now.
+func rateLimitReset(value string, now time.Time) time.Time {
+	seconds, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || seconds < 0 {
+		return time.Time{}
+	}
+	if seconds >= unixTimestampThreshold {
+		return time.Unix(seconds, 0)
+	}
+	return now.Add(time.Duration(seconds) * time.Second)
+}
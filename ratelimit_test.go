@@ -0,0 +1,96 @@
+package tama_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	tama "github.com/upmaru/tama-go"
+)
+
+func TestRateLimitedError(t *testing.T) {
+	server := createMockServer(t, func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Retry-After", "30")
+		w.Header().Set("X-RateLimit-Limit", "100")
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		w.WriteHeader(http.StatusTooManyRequests)
+	})
+	defer server.Close()
+
+	client := tama.NewClient(tama.Config{BaseURL: server.URL, APIKey: "test-key"})
+
+	before := time.Now()
+	_, err := client.Sensory.GetLimit("limit-1")
+
+	var limited *tama.RateLimitedError
+	if !errors.As(err, &limited) {
+		t.Fatalf("Expected RateLimitedError, got %T: %v", err, err)
+	}
+
+	if limited.ResetAt.Before(before.Add(29 * time.Second)) {
+		t.Errorf("Expected reset time about 30s from now, got %s", limited.ResetAt)
+	}
+
+	if limited.Limit != 100 || limited.Remaining != 0 {
+		t.Errorf("Expected limit 100 and remaining 0, got %d and %d", limited.Limit, limited.Remaining)
+	}
+}
+
+func TestRateLimitHeadersPauseRequests(t *testing.T) {
+	var calls atomic.Int32
+	server := createMockServer(t, func(w http.ResponseWriter, _ *http.Request) {
+		calls.Add(1)
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		w.Header().Set("X-RateLimit-Reset", "60")
+		w.WriteHeader(http.StatusNoContent)
+	})
+	defer server.Close()
+
+	client := tama.NewClient(tama.Config{BaseURL: server.URL, APIKey: "test-key"})
+
+	if err := client.Neural.DeleteSpace("space-1"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	err := client.Neural.DeleteSpaceWithContext(ctx, "space-2")
+
+	var limited *tama.RateLimitedError
+	if !errors.As(err, &limited) || !limited.Local {
+		t.Fatalf("Expected a local RateLimitedError while the limit is exhausted, got %v", err)
+	}
+
+	if calls.Load() != 1 {
+		t.Errorf("Expected the second call to be held back, got %d calls", calls.Load())
+	}
+}
+
+func TestClientSideThrottle(t *testing.T) {
+	server := createMockServer(t, func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	defer server.Close()
+
+	client := tama.NewClient(tama.Config{
+		BaseURL:   server.URL,
+		APIKey:    "test-key",
+		RateLimit: &tama.RateLimit{RequestsPerSecond: 50, Burst: 1},
+	})
+
+	start := time.Now()
+	for range 4 {
+		if err := client.Memory.DeletePrompt("prompt-1"); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+
+	// One request goes out immediately; the other three wait 20ms each.
+	if elapsed := time.Since(start); elapsed < 55*time.Millisecond {
+		t.Errorf("Expected throttled requests to take at least 55ms, took %s", elapsed)
+	}
+}
@@ -0,0 +1,97 @@
+package tama
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// redacted replaces secret values in logs and other diagnostic output.
+const redacted = "[REDACTED]"
+
+// redactHeader returns a copy of header with secret values replaced.
+func redactHeader(header http.Header) http.Header {
+	clone := header.Clone()
+	for name := range clone {
+		if isSensitiveHeader(name) {
+			clone[name] = []string{redacted}
+		}
+	}
+	return clone
+}
+
+// isSensitiveHeader reports whether a header's values are never written out.
+func isSensitiveHeader(name string) bool {
+	switch http.CanonicalHeaderKey(name) {
+	case "Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-Api-Key":
+		return true
+	default:
+		return false
+	}
+}
+
+// redactBody returns a copy of a JSON body with secret fields replaced. Bodies
+// that are not JSON are replaced entirely, since they cannot be inspected.
+func redactBody(body []byte) []byte {
+	if len(body) == 0 {
+		return body
+	}
+
+	var value any
+	if err := json.Unmarshal(body, &value); err != nil {
+		return []byte(redacted)
+	}
+
+	data, err := json.Marshal(redactValue(value, false))
+	if err != nil {
+		return []byte(redacted)
+	}
+	return data
+}
+
+// redactValue walks a decoded JSON value. Inside a credential object every
+// string is redacted.
+func redactValue(value any, secret bool) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, field := range v {
+			v[key] = redactValue(field, secret || isCredentialKey(key) || isSensitiveKey(key))
+		}
+		return v
+	case []any:
+		for i, item := range v {
+			v[i] = redactValue(item, secret)
+		}
+		return v
+	case string:
+		if secret {
+			return redacted
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+// isSensitiveKey reports whether a JSON field holds a secret. Keys are
+// compared in lower case with "_" and "-" removed.
+func isSensitiveKey(key string) bool {
+	normalized := strings.NewReplacer("_", "", "-", "").Replace(strings.ToLower(key))
+	for _, part := range []string{"apikey", "secret", "password", "token", "authorization", "privatekey"} {
+		if strings.Contains(normalized, part) {
+			return true
+		}
+	}
+	return false
+}
+
+// isCredentialKey reports whether a JSON field holds a credential object,
+// whose string fields are all redacted.
+func isCredentialKey(key string) bool {
+	switch strings.ToLower(key) {
+	case "credential", "credentials":
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,244 @@
+package tama
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+)
+
+const (
+	// DefaultRetryMaxAttempts is the default number of attempts, including the first one.
+	DefaultRetryMaxAttempts = 3
+	// DefaultRetryBaseDelay is the default delay before the first retry.
+	DefaultRetryBaseDelay = 200 * time.Millisecond
+	// DefaultRetryMaxDelay is the default upper bound for the backoff delay.
+	DefaultRetryMaxDelay = 5 * time.Second
+	// DefaultRetryJitter is the default fraction of each delay that is randomised.
+	DefaultRetryJitter = 0.5
+)
+
+// RetryPolicy controls automatic retries of failed requests.
+//
+// Requests are retried when the connection fails or the server answers with
+// one of RetryableStatusCodes. Only GET, PUT and DELETE requests are retried
+// unless RetryNonIdempotent is set, in which case POST and PATCH are retried as
+// well. Create calls carry an Idempotency-Key header that stays the same on
+// every retry, so the API can discard duplicates. A Retry-After header on the
+// response takes precedence over the computed backoff delay.
+//
+// The timeout of the call, from Config.Timeout or Config.Timeouts, bounds the
+// whole call, including every retry and the delays between them.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first one.
+	// Zero means DefaultRetryMaxAttempts; one disables retries.
+	MaxAttempts int
+	// BaseDelay is the delay before the first retry. It doubles on every
+	// subsequent retry. Zero means DefaultRetryBaseDelay.
+	BaseDelay time.Duration
+	// MaxDelay caps the exponential backoff. Zero means DefaultRetryMaxDelay.
+	MaxDelay time.Duration
+	// Jitter is the fraction (0 to 1) of each delay that is randomised.
+	// Zero disables jitter.
+	Jitter float64
+	// RetryableStatusCodes lists the HTTP status codes that trigger a retry.
+	// Nil means 429, 502, 503 and 504.
+	RetryableStatusCodes []int
+	// RetryNonIdempotent allows POST and PATCH requests to be retried.
+	RetryNonIdempotent bool
+}
+
+// DefaultRetryPolicy returns a retry policy with the default settings.
+func DefaultRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		MaxAttempts: DefaultRetryMaxAttempts,
+		BaseDelay:   DefaultRetryBaseDelay,
+		MaxDelay:    DefaultRetryMaxDelay,
+		Jitter:      DefaultRetryJitter,
+	}
+}
+
+// RetryError is returned when a request could not be completed because of a
+// transport failure. It records how many attempts were made.
+type RetryError struct {
+	Attempts int
+	Err      error
+}
+
+func (e *RetryError) Error() string {
+	return fmt.Sprintf("request failed after %d attempt(s): %v", e.Attempts, e.Err)
+}
+
+func (e *RetryError) Unwrap() error {
+	return e.Err
+}
+
+// withDefaults returns a copy of the policy with zero values replaced by defaults.
+func (p RetryPolicy) withDefaults() RetryPolicy {
+	if p.MaxAttempts == 0 {
+		p.MaxAttempts = DefaultRetryMaxAttempts
+	}
+	if p.BaseDelay == 0 {
+		p.BaseDelay = DefaultRetryBaseDelay
+	}
+	if p.MaxDelay == 0 {
+		p.MaxDelay = DefaultRetryMaxDelay
+	}
+	if p.RetryableStatusCodes == nil {
+		p.RetryableStatusCodes = []int{
+			http.StatusTooManyRequests,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout,
+		}
+	}
+	return p
+}
+
+// allowsMethod reports whether requests with the given method may be retried.
+func (p RetryPolicy) allowsMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete, http.MethodOptions:
+		return true
+	case http.MethodPost, http.MethodPatch:
+		return p.RetryNonIdempotent
+	default:
+		return false
+	}
+}
+
+// backoff returns the delay before the given retry (1 for the first retry).
+func (p RetryPolicy) backoff(retry int) time.Duration {
+	delay := p.BaseDelay << (retry - 1)
+	if delay <= 0 || delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	if p.Jitter > 0 {
+		jitter := time.Duration(float64(delay) * min(p.Jitter, 1))
+		delay = delay - jitter + rand.N(jitter+1) //nolint:gosec // jitter does not need a secure source
+	}
+	return delay
+}
+
+// retryTransport is an http.RoundTripper that retries failed requests
+// according to a RetryPolicy.
+type retryTransport struct {
+	next   http.RoundTripper
+	policy RetryPolicy
+}
+
+// newRetryTransport wraps next with the given retry policy.
+func newRetryTransport(next http.RoundTripper, policy RetryPolicy) *retryTransport {
+	return &retryTransport{next: next, policy: policy.withDefaults()}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	maxAttempts := t.policy.MaxAttempts
+	if !t.policy.allowsMethod(req.Method) {
+		maxAttempts = 1
+	}
+
+	for attempt := 1; ; attempt++ {
+		attemptReq := req
+		if attempt > 1 {
+			var err error
+			if attemptReq, err = replay(req); err != nil {
+				return nil, &RetryError{Attempts: attempt - 1, Err: err}
+			}
+		}
+
+		resp, err := t.next.RoundTrip(attemptReq)
+		if attempt >= maxAttempts || !t.shouldRetry(ctx, resp, err) {
+			if err != nil {
+				return nil, &RetryError{Attempts: attempt, Err: err}
+			}
+			return resp, nil
+		}
+
+		delay := t.policy.backoff(attempt)
+		if resp != nil {
+			if after, ok := retryAfter(resp.Header.Get("Retry-After")); ok {
+				delay = after
+			}
+			drain(resp)
+		}
+
+		if err := sleep(ctx, delay); err != nil {
+			return nil, &RetryError{Attempts: attempt, Err: err}
+		}
+	}
+}
+
+// shouldRetry reports whether the outcome of an attempt warrants a retry.
+func (t *retryTransport) shouldRetry(ctx context.Context, resp *http.Response, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	var limited *RateLimitedError
+	if errors.As(err, &limited) {
+		return !limited.Local && slices.Contains(t.policy.RetryableStatusCodes, http.StatusTooManyRequests)
+	}
+	if err != nil {
+		return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+	}
+	return slices.Contains(t.policy.RetryableStatusCodes, resp.StatusCode)
+}
+
+// replay returns a copy of req with a fresh body so that it can be sent again.
+func replay(req *http.Request) (*http.Request, error) {
+	clone := req.Clone(req.Context())
+	if req.Body == nil || req.Body == http.NoBody {
+		return clone, nil
+	}
+	if req.GetBody == nil {
+		return nil, errors.New("request body cannot be replayed")
+	}
+
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, fmt.Errorf("failed to replay request body: %w", err)
+	}
+
+	clone.Body = body
+	return clone, nil
+}
+
+// retryAfter parses a Retry-After header given either in seconds or as an HTTP date.
+func retryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return max(time.Duration(seconds)*time.Second, 0), true
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return max(time.Until(at), 0), true
+	}
+	return 0, false
+}
+
+// drain discards and closes a response body so the connection can be reused.
+func drain(resp *http.Response) {
+	_, _ = io.Copy(io.Discard, resp.Body)
+	_ = resp.Body.Close()
+}
+
+// sleep waits for d or until ctx is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
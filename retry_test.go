@@ -0,0 +1,161 @@
+package tama_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	tama "github.com/upmaru/tama-go"
+	"github.com/upmaru/tama-go/memory"
+	"github.com/upmaru/tama-go/neural"
+)
+
+func fastRetryPolicy() *tama.RetryPolicy {
+	return &tama.RetryPolicy{
+		MaxAttempts: 3,
+		BaseDelay:   time.Millisecond,
+		MaxDelay:    5 * time.Millisecond,
+		Jitter:      0.5,
+	}
+}
+
+func TestRetryGetOnServiceUnavailable(t *testing.T) {
+	var calls atomic.Int32
+	server := createMockServer(t, func(w http.ResponseWriter, _ *http.Request) {
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(neural.SpaceResponse{Data: neural.Space{ID: "space-123"}})
+	})
+	defer server.Close()
+
+	client := tama.NewClient(tama.Config{BaseURL: server.URL, APIKey: "test-key", Retry: fastRetryPolicy()})
+
+	space, err := client.Neural.GetSpace("space-123")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if space.ID != "space-123" {
+		t.Errorf("Expected space ID space-123, got %s", space.ID)
+	}
+
+	if calls.Load() != 3 {
+		t.Errorf("Expected 3 attempts, got %d", calls.Load())
+	}
+}
+
+func TestRetryAttemptsOnError(t *testing.T) {
+	var calls atomic.Int32
+	server := createMockServer(t, func(w http.ResponseWriter, _ *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadGateway)
+		w.Write([]byte(`{"errors": {"upstream": ["unavailable"]}}`))
+	})
+	defer server.Close()
+
+	client := tama.NewClient(tama.Config{BaseURL: server.URL, APIKey: "test-key", Retry: fastRetryPolicy()})
+
+	err := client.Neural.DeleteSpace("space-123")
+
+	var neuralErr *neural.Error
+	if !errors.As(err, &neuralErr) {
+		t.Fatalf("Expected neural.Error, got %T: %v", err, err)
+	}
+
+	if neuralErr.Attempts != 3 {
+		t.Errorf("Expected 3 attempts on error, got %d", neuralErr.Attempts)
+	}
+
+	if calls.Load() != 3 {
+		t.Errorf("Expected server to see 3 attempts, got %d", calls.Load())
+	}
+}
+
+func TestRetrySkipsPostByDefault(t *testing.T) {
+	var calls atomic.Int32
+	server := createMockServer(t, func(w http.ResponseWriter, _ *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	defer server.Close()
+
+	client := tama.NewClient(tama.Config{BaseURL: server.URL, APIKey: "test-key", Retry: fastRetryPolicy()})
+
+	_, err := client.Memory.CreatePrompt("space-123", memory.CreatePromptRequest{
+		Prompt: memory.PromptRequestData{Name: "Prompt", Content: "Content", Role: "system"},
+	})
+	if err == nil {
+		t.Fatal("Expected error, got nil")
+	}
+
+	if calls.Load() != 1 {
+		t.Errorf("Expected POST not to be retried, got %d attempts", calls.Load())
+	}
+}
+
+func TestRetryPostWhenAllowed(t *testing.T) {
+	var calls atomic.Int32
+	server := createMockServer(t, func(w http.ResponseWriter, r *http.Request) {
+		var req memory.CreatePromptRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("Failed to decode replayed body: %v", err)
+		}
+
+		if calls.Add(1) == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(memory.PromptResponse{Data: memory.Prompt{ID: "prompt-1", Name: req.Prompt.Name}})
+	})
+	defer server.Close()
+
+	policy := fastRetryPolicy()
+	policy.RetryNonIdempotent = true
+	client := tama.NewClient(tama.Config{BaseURL: server.URL, APIKey: "test-key", Retry: policy})
+
+	prompt, err := client.Memory.CreatePrompt("space-123", memory.CreatePromptRequest{
+		Prompt: memory.PromptRequestData{Name: "Prompt", Content: "Content", Role: "system"},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if prompt.Name != "Prompt" {
+		t.Errorf("Expected prompt name Prompt, got %s", prompt.Name)
+	}
+
+	if calls.Load() != 2 {
+		t.Errorf("Expected 2 attempts, got %d", calls.Load())
+	}
+}
+
+func TestRetryTransportError(t *testing.T) {
+	server := createMockServer(t, func(_ http.ResponseWriter, _ *http.Request) {})
+	url := server.URL
+	server.Close()
+
+	client := tama.NewClient(tama.Config{BaseURL: url, APIKey: "test-key", Retry: fastRetryPolicy()})
+
+	_, err := client.Neural.GetSpace("space-123")
+
+	var retryErr *tama.RetryError
+	if !errors.As(err, &retryErr) {
+		t.Fatalf("Expected RetryError, got %T: %v", err, err)
+	}
+
+	if retryErr.Attempts != 3 {
+		t.Errorf("Expected 3 attempts, got %d", retryErr.Attempts)
+	}
+}
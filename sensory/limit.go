@@ -2,8 +2,10 @@
 package sensory
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"net/http"
 )
 
 // This file contains all Limit-related operations for the SensoryService.
@@ -14,14 +16,19 @@ import (
 // GetLimit retrieves a specific limit by ID.
 // GET /provision/sensory/limits/:id.
 func (s *Service) GetLimit(id string) (*Limit, error) {
+	return s.GetLimitWithContext(context.Background(), id)
+}
+
+// GetLimitWithContext is like GetLimit but uses ctx for the request.
+// GET /provision/sensory/limits/:id.
+func (s *Service) GetLimitWithContext(ctx context.Context, id string) (*Limit, error) {
 	if id == "" {
 		return nil, errors.New("limit ID is required")
 	}
 
 	var limitResp LimitResponse
-	resp, err := s.client.R().
-		SetResult(&limitResp).
-		Get(fmt.Sprintf("/provision/sensory/limits/%s", id))
+	resp, err := s.send(ctx, "GetLimit", http.MethodGet,
+		"/provision/sensory/limits/{id}", id, nil, &limitResp)
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to get limit: %w", err)
@@ -37,6 +44,12 @@ func (s *Service) GetLimit(id string) (*Limit, error) {
 // CreateLimit creates a new limit for a specific source.
 // POST /provision/sensory/sources/:source_id/limits.
 func (s *Service) CreateLimit(sourceID string, req CreateLimitRequest) (*Limit, error) {
+	return s.CreateLimitWithContext(context.Background(), sourceID, req)
+}
+
+// CreateLimitWithContext is like CreateLimit but uses ctx for the request.
+// POST /provision/sensory/sources/:source_id/limits.
+func (s *Service) CreateLimitWithContext(ctx context.Context, sourceID string, req CreateLimitRequest) (*Limit, error) {
 	if sourceID == "" {
 		return nil, errors.New("source ID is required")
 	}
@@ -51,10 +64,8 @@ func (s *Service) CreateLimit(sourceID string, req CreateLimitRequest) (*Limit,
 	}
 
 	var limitResp LimitResponse
-	resp, err := s.client.R().
-		SetBody(req).
-		SetResult(&limitResp).
-		Post(fmt.Sprintf("/provision/sensory/sources/%s/limits", sourceID))
+	resp, err := s.send(ctx, "CreateLimit", http.MethodPost,
+		"/provision/sensory/sources/{source_id}/limits", sourceID, req, &limitResp)
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to create limit: %w", err)
@@ -70,15 +81,19 @@ func (s *Service) CreateLimit(sourceID string, req CreateLimitRequest) (*Limit,
 // UpdateLimit updates an existing limit using PATCH.
 // PATCH /provision/sensory/limits/:id.
 func (s *Service) UpdateLimit(id string, req UpdateLimitRequest) (*Limit, error) {
+	return s.UpdateLimitWithContext(context.Background(), id, req)
+}
+
+// UpdateLimitWithContext is like UpdateLimit but uses ctx for the request.
+// PATCH /provision/sensory/limits/:id.
+func (s *Service) UpdateLimitWithContext(ctx context.Context, id string, req UpdateLimitRequest) (*Limit, error) {
 	if id == "" {
 		return nil, errors.New("limit ID is required")
 	}
 
 	var limitResp LimitResponse
-	resp, err := s.client.R().
-		SetBody(req).
-		SetResult(&limitResp).
-		Patch(fmt.Sprintf("/provision/sensory/limits/%s", id))
+	resp, err := s.send(ctx, "UpdateLimit", http.MethodPatch,
+		"/provision/sensory/limits/{id}", id, req, &limitResp)
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to update limit: %w", err)
@@ -94,15 +109,19 @@ func (s *Service) UpdateLimit(id string, req UpdateLimitRequest) (*Limit, error)
 // ReplaceLimit replaces an existing limit using PUT.
 // PUT /provision/sensory/limits/:id.
 func (s *Service) ReplaceLimit(id string, req UpdateLimitRequest) (*Limit, error) {
+	return s.ReplaceLimitWithContext(context.Background(), id, req)
+}
+
+// ReplaceLimitWithContext is like ReplaceLimit but uses ctx for the request.
+// PUT /provision/sensory/limits/:id.
+func (s *Service) ReplaceLimitWithContext(ctx context.Context, id string, req UpdateLimitRequest) (*Limit, error) {
 	if id == "" {
 		return nil, errors.New("limit ID is required")
 	}
 
 	var limitResp LimitResponse
-	resp, err := s.client.R().
-		SetBody(req).
-		SetResult(&limitResp).
-		Put(fmt.Sprintf("/provision/sensory/limits/%s", id))
+	resp, err := s.send(ctx, "ReplaceLimit", http.MethodPut,
+		"/provision/sensory/limits/{id}", id, req, &limitResp)
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to replace limit: %w", err)
@@ -118,12 +137,18 @@ func (s *Service) ReplaceLimit(id string, req UpdateLimitRequest) (*Limit, error
 // DeleteLimit deletes a limit by ID.
 // DELETE /provision/sensory/limits/:id.
 func (s *Service) DeleteLimit(id string) error {
+	return s.DeleteLimitWithContext(context.Background(), id)
+}
+
+// DeleteLimitWithContext is like DeleteLimit but uses ctx for the request.
+// DELETE /provision/sensory/limits/:id.
+func (s *Service) DeleteLimitWithContext(ctx context.Context, id string) error {
 	if id == "" {
 		return errors.New("limit ID is required")
 	}
 
-	resp, err := s.client.R().
-		Delete(fmt.Sprintf("/provision/sensory/limits/%s", id))
+	resp, err := s.send(ctx, "DeleteLimit", http.MethodDelete,
+		"/provision/sensory/limits/{id}", id, nil, nil)
 
 	if err != nil {
 		return fmt.Errorf("failed to delete limit: %w", err)
@@ -1,8 +1,10 @@
 package sensory
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"net/http"
 )
 
 // This file contains all Model-related operations for the SensoryService.
@@ -13,14 +15,19 @@ import (
 // GetModel retrieves a specific model by ID.
 // GET /provision/sensory/models/:id.
 func (s *Service) GetModel(id string) (*Model, error) {
+	return s.GetModelWithContext(context.Background(), id)
+}
+
+// GetModelWithContext is like GetModel but uses ctx for the request.
+// GET /provision/sensory/models/:id.
+func (s *Service) GetModelWithContext(ctx context.Context, id string) (*Model, error) {
 	if id == "" {
 		return nil, errors.New("model ID is required")
 	}
 
 	var modelResp ModelResponse
-	resp, err := s.client.R().
-		SetResult(&modelResp).
-		Get(fmt.Sprintf("/provision/sensory/models/%s", id))
+	resp, err := s.send(ctx, "GetModel", http.MethodGet,
+		"/provision/sensory/models/{id}", id, nil, &modelResp)
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to get model: %w", err)
@@ -36,6 +43,12 @@ func (s *Service) GetModel(id string) (*Model, error) {
 // CreateModel creates a new model for a specific source.
 // POST /provision/sensory/sources/:source_id/models.
 func (s *Service) CreateModel(sourceID string, req CreateModelRequest) (*Model, error) {
+	return s.CreateModelWithContext(context.Background(), sourceID, req)
+}
+
+// CreateModelWithContext is like CreateModel but uses ctx for the request.
+// POST /provision/sensory/sources/:source_id/models.
+func (s *Service) CreateModelWithContext(ctx context.Context, sourceID string, req CreateModelRequest) (*Model, error) {
 	if sourceID == "" {
 		return nil, errors.New("source ID is required")
 	}
@@ -47,10 +60,8 @@ func (s *Service) CreateModel(sourceID string, req CreateModelRequest) (*Model,
 	}
 
 	var modelResp ModelResponse
-	resp, err := s.client.R().
-		SetBody(req).
-		SetResult(&modelResp).
-		Post(fmt.Sprintf("/provision/sensory/sources/%s/models", sourceID))
+	resp, err := s.send(ctx, "CreateModel", http.MethodPost,
+		"/provision/sensory/sources/{source_id}/models", sourceID, req, &modelResp)
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to create model: %w", err)
@@ -66,15 +77,19 @@ func (s *Service) CreateModel(sourceID string, req CreateModelRequest) (*Model,
 // UpdateModel updates an existing model using PATCH.
 // PATCH /provision/sensory/models/:id.
 func (s *Service) UpdateModel(id string, req UpdateModelRequest) (*Model, error) {
+	return s.UpdateModelWithContext(context.Background(), id, req)
+}
+
+// UpdateModelWithContext is like UpdateModel but uses ctx for the request.
+// PATCH /provision/sensory/models/:id.
+func (s *Service) UpdateModelWithContext(ctx context.Context, id string, req UpdateModelRequest) (*Model, error) {
 	if id == "" {
 		return nil, errors.New("model ID is required")
 	}
 
 	var modelResp ModelResponse
-	resp, err := s.client.R().
-		SetBody(req).
-		SetResult(&modelResp).
-		Patch(fmt.Sprintf("/provision/sensory/models/%s", id))
+	resp, err := s.send(ctx, "UpdateModel", http.MethodPatch,
+		"/provision/sensory/models/{id}", id, req, &modelResp)
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to update model: %w", err)
@@ -90,15 +105,19 @@ func (s *Service) UpdateModel(id string, req UpdateModelRequest) (*Model, error)
 // ReplaceModel replaces an existing model using PUT.
 // PUT /provision/sensory/models/:id.
 func (s *Service) ReplaceModel(id string, req UpdateModelRequest) (*Model, error) {
+	return s.ReplaceModelWithContext(context.Background(), id, req)
+}
+
+// ReplaceModelWithContext is like ReplaceModel but uses ctx for the request.
+// PUT /provision/sensory/models/:id.
+func (s *Service) ReplaceModelWithContext(ctx context.Context, id string, req UpdateModelRequest) (*Model, error) {
 	if id == "" {
 		return nil, errors.New("model ID is required")
 	}
 
 	var modelResp ModelResponse
-	resp, err := s.client.R().
-		SetBody(req).
-		SetResult(&modelResp).
-		Put(fmt.Sprintf("/provision/sensory/models/%s", id))
+	resp, err := s.send(ctx, "ReplaceModel", http.MethodPut,
+		"/provision/sensory/models/{id}", id, req, &modelResp)
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to replace model: %w", err)
@@ -114,12 +133,18 @@ func (s *Service) ReplaceModel(id string, req UpdateModelRequest) (*Model, error
 // DeleteModel deletes a model by ID.
 // DELETE /provision/sensory/models/:id.
 func (s *Service) DeleteModel(id string) error {
+	return s.DeleteModelWithContext(context.Background(), id)
+}
+
+// DeleteModelWithContext is like DeleteModel but uses ctx for the request.
+// DELETE /provision/sensory/models/:id.
+func (s *Service) DeleteModelWithContext(ctx context.Context, id string) error {
 	if id == "" {
 		return errors.New("model ID is required")
 	}
 
-	resp, err := s.client.R().
-		Delete(fmt.Sprintf("/provision/sensory/models/%s", id))
+	resp, err := s.send(ctx, "DeleteModel", http.MethodDelete,
+		"/provision/sensory/models/{id}", id, nil, nil)
 
 	if err != nil {
 		return fmt.Errorf("failed to delete model: %w", err)
@@ -1,22 +1,36 @@
 package sensory
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"net/http"
 	"strings"
 
-	"github.com/go-resty/resty/v2"
+	"github.com/upmaru/tama-go/internal/transport"
 )
 
+// Doer sends HTTP requests. *http.Client satisfies it, as does any client that
+// wraps one with extra behaviour.
+type Doer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
 // Service handles all sensory-related API operations.
 type Service struct {
-	client *resty.Client
+	doer    Doer
+	baseURL string
 }
 
-// NewService creates a new sensory service instance.
-func NewService(client *resty.Client) *Service {
+// NewService creates a new sensory service instance that sends requests with doer
+// to the API at baseURL.
+func NewService(doer Doer, baseURL string) *Service {
 	return &Service{
-		client: client,
+		doer:    doer,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
 	}
 }
 
@@ -24,9 +38,20 @@ func NewService(client *resty.Client) *Service {
 type Error struct {
 	StatusCode int                 `json:"status_code"`
 	Errors     map[string][]string `json:"errors,omitempty"`
+	// Attempts is the number of HTTP attempts made before the error was returned.
+	Attempts int `json:"-"`
 }
 
 func (e *Error) Error() string {
+	msg := e.message()
+	if e.Attempts > 1 {
+		return fmt.Sprintf("%s (after %d attempts)", msg, e.Attempts)
+	}
+	return msg
+}
+
+// message formats the status code and field errors.
+func (e *Error) message() string {
 	if len(e.Errors) > 0 {
 		var errorParts []string
 		for field, messages := range e.Errors {
@@ -58,6 +83,9 @@ type Source struct {
 	Endpoint     string `json:"endpoint"`
 	SpaceID      string `json:"space_id"`
 	CurrentState string `json:"current_state"`
+	// ETag is the entity tag the API returned with the resource. Pass it to
+	// tama.WithIfMatch to make a later write conditional.
+	ETag string `json:"-"`
 }
 
 // Model represents a sensory model resource.
@@ -67,6 +95,9 @@ type Model struct {
 	Path         string         `json:"path"`
 	Parameters   map[string]any `json:"parameters,omitempty"`
 	CurrentState string         `json:"current_state"`
+	// ETag is the entity tag the API returned with the resource. Pass it to
+	// tama.WithIfMatch to make a later write conditional.
+	ETag string `json:"-"`
 }
 
 // Limit represents a sensory limit resource.
@@ -77,6 +108,9 @@ type Limit struct {
 	ScaleUnit    string `json:"scale_unit"`
 	ScaleCount   int    `json:"scale_count"`
 	CurrentState string `json:"current_state"`
+	// ETag is the entity tag the API returned with the resource. Pass it to
+	// tama.WithIfMatch to make a later write conditional.
+	ETag string `json:"-"`
 }
 
 // SourceResponse represents the API response for source operations.
@@ -84,16 +118,28 @@ type SourceResponse struct {
 	Data Source `json:"data"`
 }
 
+func (r *SourceResponse) setETag(etag string) {
+	r.Data.ETag = etag
+}
+
 // ModelResponse represents the API response for model operations.
 type ModelResponse struct {
 	Data Model `json:"data"`
 }
 
+func (r *ModelResponse) setETag(etag string) {
+	r.Data.ETag = etag
+}
+
 // LimitResponse represents the API response for limit operations.
 type LimitResponse struct {
 	Data Limit `json:"data"`
 }
 
+func (r *LimitResponse) setETag(etag string) {
+	r.Data.ETag = etag
+}
+
 // CreateSourceRequest represents the request payload for creating a source.
 type CreateSourceRequest struct {
 	Source SourceRequestData `json:"source"`
@@ -169,36 +215,99 @@ type UpdateLimitData struct {
 	CurrentState string `json:"current_state,omitempty"`
 }
 
-// handleAPIError processes API error responses.
-func (s *Service) handleAPIError(resp interface{}) error {
-	errResp, ok := s.extractErrorResponse(resp)
-	if !ok {
-		return nil
+// etagged is implemented by responses whose resource records the ETag header.
+type etagged interface {
+	setETag(etag string)
+}
+
+// response holds the outcome of an HTTP request.
+type response struct {
+	statusCode int
+	status     string
+	body       []byte
+	call       *transport.Call
+}
+
+// send issues a request for the named operation against route, a path template
+// whose placeholder is filled with id. It encodes body as JSON when it is not
+// nil and decodes a successful response into result when it is not nil.
+func (s *Service) send(
+	ctx context.Context, operation, method, route, id string, body, result any,
+) (*response, error) {
+	call := transport.NewCall("sensory."+operation, method, route, id)
+	ctx = transport.NewContext(ctx, call)
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, s.baseURL+call.Path(), reader)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
 	}
 
-	if body := errResp.Body(); len(body) > 0 {
-		if err := s.parseErrorFromBody(body, errResp.StatusCode()); err != nil {
-			return err
+	httpResp, err := s.doer.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer httpResp.Body.Close()
+
+	data, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	resp := &response{
+		statusCode: httpResp.StatusCode,
+		status:     httpResp.Status,
+		body:       data,
+		call:       call,
+	}
+
+	if result != nil && !resp.isError() && len(data) > 0 {
+		if err := json.Unmarshal(data, result); err != nil {
+			return nil, fmt.Errorf("failed to decode response: %w", err)
 		}
 	}
 
-	return s.fallbackError(errResp)
+	if tagged, ok := result.(etagged); ok && !resp.isError() {
+		tagged.setETag(httpResp.Header.Get("ETag"))
+	}
+
+	return resp, nil
+}
+
+// isError reports whether the response carries an error status code.
+func (r *response) isError() bool {
+	return r.statusCode > 399
 }
 
-// extractErrorResponse extracts error response interface from resp.
-func (s *Service) extractErrorResponse(resp interface{}) (errorResponse, bool) {
-	type errorResponse interface {
-		IsError() bool
-		Error() interface{}
-		StatusCode() int
-		Status() string
-		Body() []byte
+// handleAPIError processes API error responses.
+func (s *Service) handleAPIError(resp *response) error {
+	if !resp.isError() {
+		return nil
 	}
 
-	if errResp, ok := resp.(errorResponse); ok && errResp.IsError() {
-		return errResp, true
+	err := s.parseErrorFromBody(resp.body, resp.statusCode)
+	if err == nil {
+		err = fmt.Errorf("API error: %s", resp.status)
 	}
-	return nil, false
+
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		apiErr.Attempts = resp.call.Attempts
+	}
+
+	return err
 }
 
 // parseErrorFromBody attempts to parse error from response body.
@@ -245,21 +354,3 @@ func (s *Service) parseStringError(body []byte, statusCode int) error {
 	}
 	return nil
 }
-
-// fallbackError handles fallback error cases.
-func (s *Service) fallbackError(errResp errorResponse) error {
-	if apiErrorResp, isError := errResp.Error().(*Error); isError {
-		apiErrorResp.StatusCode = errResp.StatusCode()
-		return apiErrorResp
-	}
-	return fmt.Errorf("API error: %s", errResp.Status())
-}
-
-// errorResponse interface for type assertion.
-type errorResponse interface {
-	IsError() bool
-	Error() interface{}
-	StatusCode() int
-	Status() string
-	Body() []byte
-}
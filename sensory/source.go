@@ -2,8 +2,10 @@
 package sensory
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"net/http"
 )
 
 // This file contains all Source-related operations for the SensoryService.
@@ -14,14 +16,19 @@ import (
 // GetSource retrieves a specific source by ID.
 // GET /provision/sensory/sources/:id.
 func (s *Service) GetSource(id string) (*Source, error) {
+	return s.GetSourceWithContext(context.Background(), id)
+}
+
+// GetSourceWithContext is like GetSource but uses ctx for the request.
+// GET /provision/sensory/sources/:id.
+func (s *Service) GetSourceWithContext(ctx context.Context, id string) (*Source, error) {
 	if id == "" {
 		return nil, errors.New("source ID is required")
 	}
 
 	var sourceResp SourceResponse
-	resp, err := s.client.R().
-		SetResult(&sourceResp).
-		Get(fmt.Sprintf("/provision/sensory/sources/%s", id))
+	resp, err := s.send(ctx, "GetSource", http.MethodGet,
+		"/provision/sensory/sources/{id}", id, nil, &sourceResp)
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to get source: %w", err)
@@ -37,6 +44,14 @@ func (s *Service) GetSource(id string) (*Source, error) {
 // CreateSource creates a new source in a specific space.
 // POST /provision/sensory/spaces/:space_id/sources.
 func (s *Service) CreateSource(spaceID string, req CreateSourceRequest) (*Source, error) {
+	return s.CreateSourceWithContext(context.Background(), spaceID, req)
+}
+
+// CreateSourceWithContext is like CreateSource but uses ctx for the request.
+// POST /provision/sensory/spaces/:space_id/sources.
+func (s *Service) CreateSourceWithContext(
+	ctx context.Context, spaceID string, req CreateSourceRequest,
+) (*Source, error) {
 	if spaceID == "" {
 		return nil, errors.New("space ID is required")
 	}
@@ -51,10 +66,8 @@ func (s *Service) CreateSource(spaceID string, req CreateSourceRequest) (*Source
 	}
 
 	var sourceResp SourceResponse
-	resp, err := s.client.R().
-		SetBody(req).
-		SetResult(&sourceResp).
-		Post(fmt.Sprintf("/provision/sensory/spaces/%s/sources", spaceID))
+	resp, err := s.send(ctx, "CreateSource", http.MethodPost,
+		"/provision/sensory/spaces/{space_id}/sources", spaceID, req, &sourceResp)
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to create source: %w", err)
@@ -70,15 +83,19 @@ func (s *Service) CreateSource(spaceID string, req CreateSourceRequest) (*Source
 // UpdateSource updates an existing source using PATCH.
 // PATCH /provision/sensory/sources/:id.
 func (s *Service) UpdateSource(id string, req UpdateSourceRequest) (*Source, error) {
+	return s.UpdateSourceWithContext(context.Background(), id, req)
+}
+
+// UpdateSourceWithContext is like UpdateSource but uses ctx for the request.
+// PATCH /provision/sensory/sources/:id.
+func (s *Service) UpdateSourceWithContext(ctx context.Context, id string, req UpdateSourceRequest) (*Source, error) {
 	if id == "" {
 		return nil, errors.New("source ID is required")
 	}
 
 	var sourceResp SourceResponse
-	resp, err := s.client.R().
-		SetBody(req).
-		SetResult(&sourceResp).
-		Patch(fmt.Sprintf("/provision/sensory/sources/%s", id))
+	resp, err := s.send(ctx, "UpdateSource", http.MethodPatch,
+		"/provision/sensory/sources/{id}", id, req, &sourceResp)
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to update source: %w", err)
@@ -94,15 +111,19 @@ func (s *Service) UpdateSource(id string, req UpdateSourceRequest) (*Source, err
 // ReplaceSource replaces an existing source using PUT.
 // PUT /provision/sensory/sources/:id.
 func (s *Service) ReplaceSource(id string, req UpdateSourceRequest) (*Source, error) {
+	return s.ReplaceSourceWithContext(context.Background(), id, req)
+}
+
+// ReplaceSourceWithContext is like ReplaceSource but uses ctx for the request.
+// PUT /provision/sensory/sources/:id.
+func (s *Service) ReplaceSourceWithContext(ctx context.Context, id string, req UpdateSourceRequest) (*Source, error) {
 	if id == "" {
 		return nil, errors.New("source ID is required")
 	}
 
 	var sourceResp SourceResponse
-	resp, err := s.client.R().
-		SetBody(req).
-		SetResult(&sourceResp).
-		Put(fmt.Sprintf("/provision/sensory/sources/%s", id))
+	resp, err := s.send(ctx, "ReplaceSource", http.MethodPut,
+		"/provision/sensory/sources/{id}", id, req, &sourceResp)
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to replace source: %w", err)
@@ -118,12 +139,18 @@ func (s *Service) ReplaceSource(id string, req UpdateSourceRequest) (*Source, er
 // DeleteSource deletes a source by ID.
 // DELETE /provision/sensory/sources/:id.
 func (s *Service) DeleteSource(id string) error {
+	return s.DeleteSourceWithContext(context.Background(), id)
+}
+
+// DeleteSourceWithContext is like DeleteSource but uses ctx for the request.
+// DELETE /provision/sensory/sources/:id.
+func (s *Service) DeleteSourceWithContext(ctx context.Context, id string) error {
 	if id == "" {
 		return errors.New("source ID is required")
 	}
 
-	resp, err := s.client.R().
-		Delete(fmt.Sprintf("/provision/sensory/sources/%s", id))
+	resp, err := s.send(ctx, "DeleteSource", http.MethodDelete,
+		"/provision/sensory/sources/{id}", id, nil, nil)
 
 	if err != nil {
 		return fmt.Errorf("failed to delete source: %w", err)
@@ -3,6 +3,7 @@ package tama_test
 import (
 	"encoding/json"
 	"net/http"
+	"net/http/httptest"
 	"strings"
 	"testing"
 	"time"
@@ -1322,3 +1323,24 @@ func TestSensoryCreateSourceWithFieldErrors(t *testing.T) {
 		t.Errorf("Expected error to contain 'endpoint must use HTTPS', got %s", errorMsg)
 	}
 }
+
+func BenchmarkSensoryGetSource(b *testing.B) {
+	payload, _ := json.Marshal(sensory.SourceResponse{
+		Data: sensory.Source{ID: "source-123", Name: "bench-source", Endpoint: "https://api.example.com/v1"},
+	})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(payload)
+	}))
+	defer server.Close()
+
+	client := tama.NewClient(tama.Config{BaseURL: server.URL, APIKey: "test-key"})
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for range b.N {
+		if _, err := client.Sensory.GetSource("source-123"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+// Package tamaotel adapts OpenTelemetry tracing to the tama.Tracer interface.
+//
+//	client := tama.NewClient(tama.Config{
+//		BaseURL: "https://api.tama.io",
+//		APIKey:  "your-api-key",
+//		Tracer:  tamaotel.NewTracer(otel.GetTracerProvider()),
+//	})
+package tamaotel
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
+	tama "github.com/upmaru/tama-go"
+)
+
+// InstrumentationName identifies the spans created by this package.
+const InstrumentationName = "github.com/upmaru/tama-go"
+
+// Tracer implements tama.Tracer with an OpenTelemetry tracer and propagates
+// the trace context to the API as W3C traceparent and tracestate headers.
+type Tracer struct {
+	tracer     trace.Tracer
+	propagator propagation.TextMapPropagator
+}
+
+// NewTracer creates a tracer that starts client spans with the given provider.
+func NewTracer(provider trace.TracerProvider) *Tracer {
+	return &Tracer{
+		tracer:     provider.Tracer(InstrumentationName),
+		propagator: propagation.TraceContext{},
+	}
+}
+
+// Start implements tama.Tracer.
+func (t *Tracer) Start(ctx context.Context, name string) (context.Context, tama.Span) {
+	ctx, span := t.tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindClient))
+	return ctx, &spanAdapter{span: span}
+}
+
+// Inject implements tama.HeaderInjector.
+func (t *Tracer) Inject(ctx context.Context, header http.Header) {
+	t.propagator.Inject(ctx, propagation.HeaderCarrier(header))
+}
+
+// spanAdapter implements tama.Span on top of an OpenTelemetry span.
+type spanAdapter struct {
+	span trace.Span
+}
+
+func (s *spanAdapter) SetAttributes(attrs ...tama.Attribute) {
+	kvs := make([]attribute.KeyValue, 0, len(attrs))
+	for _, attr := range attrs {
+		kvs = append(kvs, keyValue(attr))
+	}
+	s.span.SetAttributes(kvs...)
+}
+
+func (s *spanAdapter) RecordError(err error) {
+	s.span.RecordError(err)
+	s.span.SetStatus(codes.Error, err.Error())
+}
+
+func (s *spanAdapter) End() {
+	s.span.End()
+}
+
+// keyValue converts a tama attribute to its OpenTelemetry equivalent.
+func keyValue(attr tama.Attribute) attribute.KeyValue {
+	switch value := attr.Value.(type) {
+	case string:
+		return attribute.String(attr.Key, value)
+	case int:
+		return attribute.Int(attr.Key, value)
+	case int64:
+		return attribute.Int64(attr.Key, value)
+	case bool:
+		return attribute.Bool(attr.Key, value)
+	case float64:
+		return attribute.Float64(attr.Key, value)
+	default:
+		return attribute.String(attr.Key, fmt.Sprint(value))
+	}
+}
@@ -0,0 +1,113 @@
+package tamaotel_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	tama "github.com/upmaru/tama-go"
+	"github.com/upmaru/tama-go/tamaotel"
+)
+
+func TestTracerRecordsSpansAndPropagatesTraceContext(t *testing.T) {
+	var traceparent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("Traceparent")
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"data":{"id":"space-123","name":"Test Space","type":"root"}}`))
+	}))
+	defer server.Close()
+
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	client := tama.NewClient(tama.Config{
+		BaseURL: server.URL,
+		APIKey:  "test-key",
+		Tracer:  tamaotel.NewTracer(provider),
+	})
+
+	ctx, parent := provider.Tracer("test").Start(context.Background(), "parent")
+	if _, err := client.Neural.GetSpaceWithContext(ctx, "space-123"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	parent.End()
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("Expected 2 spans, got %d", len(spans))
+	}
+
+	span := spans[0]
+	if span.Name() != "tama.neural.GetSpace" {
+		t.Errorf("Expected span name tama.neural.GetSpace, got %s", span.Name())
+	}
+	if span.SpanKind() != trace.SpanKindClient {
+		t.Errorf("Expected client span, got %s", span.SpanKind())
+	}
+	if span.Parent().SpanID() != parent.SpanContext().SpanID() {
+		t.Error("Expected span to be a child of the caller's span")
+	}
+
+	expected := map[attribute.Key]attribute.Value{
+		tama.AttributeHTTPMethod:     attribute.StringValue(http.MethodGet),
+		tama.AttributeHTTPRoute:      attribute.StringValue("/provision/neural/spaces/{id}"),
+		tama.AttributeHTTPStatusCode: attribute.IntValue(http.StatusOK),
+		tama.AttributeResourceID:     attribute.StringValue("space-123"),
+	}
+	for _, kv := range span.Attributes() {
+		if want, ok := expected[kv.Key]; ok {
+			if kv.Value != want {
+				t.Errorf("Expected attribute %s to be %v, got %v", kv.Key, want.Emit(), kv.Value.Emit())
+			}
+			delete(expected, kv.Key)
+		}
+	}
+	for key := range expected {
+		t.Errorf("Expected attribute %s to be set", key)
+	}
+
+	want := "00-" + span.SpanContext().TraceID().String() + "-" + span.SpanContext().SpanID().String() + "-01"
+	if traceparent != want {
+		t.Errorf("Expected traceparent %s, got %s", want, traceparent)
+	}
+}
+
+func TestTracerMarksFailedCalls(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"errors":{"detail":"Not Found"}}`))
+	}))
+	defer server.Close()
+
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	client := tama.NewClient(tama.Config{
+		BaseURL: server.URL,
+		APIKey:  "test-key",
+		Tracer:  tamaotel.NewTracer(provider),
+	})
+
+	if _, err := client.Memory.GetPrompt("prompt-123"); err == nil {
+		t.Fatal("Expected error for missing prompt")
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("Expected 1 span, got %d", len(spans))
+	}
+	if spans[0].Status().Code != codes.Error {
+		t.Errorf("Expected error status, got %v", spans[0].Status())
+	}
+	if len(spans[0].Events()) != 1 {
+		t.Errorf("Expected an exception event, got %d events", len(spans[0].Events()))
+	}
+}
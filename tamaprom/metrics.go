@@ -0,0 +1,95 @@
+// Package tamaprom exposes tama client metrics to Prometheus.
+//
+//	metrics := tamaprom.NewMetrics()
+//	prometheus.MustRegister(metrics)
+//
+//	client := tama.NewClient(tama.Config{
+//		BaseURL: "https://api.tama.io",
+//		APIKey:  "your-api-key",
+//		Metrics: metrics,
+//	})
+package tamaprom
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	tama "github.com/upmaru/tama-go"
+)
+
+// Metrics implements tama.Metrics and prometheus.Collector. It exports:
+//
+//   - tama_client_requests_total{service,resource,operation,status_class}
+//   - tama_client_request_duration_seconds{service,resource,operation}
+//   - tama_client_requests_in_flight{service,resource,operation}
+//   - tama_client_errors_total{service,resource,operation,error_class}
+type Metrics struct {
+	requests *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+	inFlight *prometheus.GaugeVec
+	errors   *prometheus.CounterVec
+}
+
+// NewMetrics creates the collector. Register it with a prometheus.Registerer
+// before passing it to tama.Config.
+func NewMetrics() *Metrics {
+	labels := []string{"service", "resource", "operation"}
+	return &Metrics{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "tama",
+			Subsystem: "client",
+			Name:      "requests_total",
+			Help:      "Total number of Tama API calls.",
+		}, append(labels, "status_class")),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "tama",
+			Subsystem: "client",
+			Name:      "request_duration_seconds",
+			Help:      "Duration of Tama API calls, including retries.",
+			Buckets:   prometheus.DefBuckets,
+		}, labels),
+		inFlight: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: "tama",
+			Subsystem: "client",
+			Name:      "requests_in_flight",
+			Help:      "Number of Tama API calls in progress.",
+		}, labels),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "tama",
+			Subsystem: "client",
+			Name:      "errors_total",
+			Help:      "Total number of failed Tama API calls by error class.",
+		}, append(labels, "error_class")),
+	}
+}
+
+// CallStarted implements tama.Metrics.
+func (m *Metrics) CallStarted(labels tama.MetricLabels) {
+	m.inFlight.WithLabelValues(labels.Service, labels.Resource, labels.Operation).Inc()
+}
+
+// CallFinished implements tama.Metrics.
+func (m *Metrics) CallFinished(labels tama.MetricLabels, outcome tama.CallOutcome) {
+	m.inFlight.WithLabelValues(labels.Service, labels.Resource, labels.Operation).Dec()
+	m.duration.WithLabelValues(labels.Service, labels.Resource, labels.Operation).
+		Observe(outcome.Duration.Seconds())
+	m.requests.WithLabelValues(labels.Service, labels.Resource, labels.Operation, outcome.StatusClass).Inc()
+	if outcome.ErrorClass != "" {
+		m.errors.WithLabelValues(labels.Service, labels.Resource, labels.Operation, outcome.ErrorClass).Inc()
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (m *Metrics) Describe(ch chan<- *prometheus.Desc) {
+	m.requests.Describe(ch)
+	m.duration.Describe(ch)
+	m.inFlight.Describe(ch)
+	m.errors.Describe(ch)
+}
+
+// Collect implements prometheus.Collector.
+func (m *Metrics) Collect(ch chan<- prometheus.Metric) {
+	m.requests.Collect(ch)
+	m.duration.Collect(ch)
+	m.inFlight.Collect(ch)
+	m.errors.Collect(ch)
+}
@@ -0,0 +1,51 @@
+package tamaprom_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	tama "github.com/upmaru/tama-go"
+	"github.com/upmaru/tama-go/tamaprom"
+)
+
+func TestMetricsCollectsCalls(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	metrics := tamaprom.NewMetrics()
+	registry := prometheus.NewPedanticRegistry()
+	registry.MustRegister(metrics)
+
+	client := tama.NewClient(tama.Config{BaseURL: server.URL, APIKey: "test-key", Metrics: metrics})
+	if _, err := client.Sensory.GetModel("model-123"); err == nil {
+		t.Fatal("Expected error from bad gateway")
+	}
+
+	expected := `
+# HELP tama_client_errors_total Total number of failed Tama API calls by error class.
+# TYPE tama_client_errors_total counter
+tama_client_errors_total{error_class="server_error",operation="get",resource="model",service="sensory"} 1
+# HELP tama_client_requests_in_flight Number of Tama API calls in progress.
+# TYPE tama_client_requests_in_flight gauge
+tama_client_requests_in_flight{operation="get",resource="model",service="sensory"} 0
+# HELP tama_client_requests_total Total number of Tama API calls.
+# TYPE tama_client_requests_total counter
+tama_client_requests_total{operation="get",resource="model",service="sensory",status_class="5xx"} 1
+`
+	err := testutil.GatherAndCompare(registry, strings.NewReader(expected),
+		"tama_client_errors_total", "tama_client_requests_in_flight", "tama_client_requests_total")
+	if err != nil {
+		t.Error(err)
+	}
+
+	if count := testutil.CollectAndCount(metrics, "tama_client_request_duration_seconds"); count != 1 {
+		t.Errorf("Expected 1 latency histogram, got %d", count)
+	}
+}
@@ -0,0 +1,122 @@
+package tama
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"net/http"
+	"time"
+
+	"github.com/upmaru/tama-go/internal/transport"
+)
+
+// OperationTimeouts are the timeouts of reads (Get calls), writes (creates,
+// updates and replaces) and deletes. Zero leaves the timeout of that class to
+// the next, less specific setting.
+type OperationTimeouts struct {
+	Read   time.Duration
+	Write  time.Duration
+	Delete time.Duration
+}
+
+// TimeoutPolicy sets the timeout of calls by operation class, with overrides
+// per resource type. The timeout of a call is the first that is set of:
+//
+//  1. the timeout passed with WithCallTimeout,
+//  2. the timeout of its class in Resources for its resource type,
+//  3. the timeout of its class in the policy,
+//  4. Config.Timeout.
+//
+// The timeout bounds the whole call, including retries. If the caller's
+// context has an earlier deadline, that deadline applies instead.
+//
+//	Timeouts: &tama.TimeoutPolicy{
+//		Read: 2 * time.Second,
+//		Resources: map[string]tama.OperationTimeouts{
+//			"space": {Delete: 5 * time.Minute},
+//		},
+//	}
+type TimeoutPolicy struct {
+	Read   time.Duration
+	Write  time.Duration
+	Delete time.Duration
+	// Resources overrides the timeouts per resource type: "space", "source",
+	// "model", "limit" or "prompt".
+	Resources map[string]OperationTimeouts
+}
+
+// forClass returns the timeout of the operation class of method, or zero.
+func (t OperationTimeouts) forClass(method string) time.Duration {
+	switch method {
+	case http.MethodGet:
+		return t.Read
+	case http.MethodDelete:
+		return t.Delete
+	default:
+		return t.Write
+	}
+}
+
+// validate reports negative timeouts.
+func (p *TimeoutPolicy) validate() error {
+	check := func(name string, t OperationTimeouts) error {
+		for _, d := range []time.Duration{t.Read, t.Write, t.Delete} {
+			if d < 0 {
+				return fmt.Errorf("%s timeout %s must not be negative", name, d)
+			}
+		}
+		return nil
+	}
+
+	if err := check("default", p.defaults()); err != nil {
+		return err
+	}
+	for resource, timeouts := range p.Resources {
+		if err := check(resource, timeouts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// defaults returns the timeouts that apply to every resource type.
+func (p *TimeoutPolicy) defaults() OperationTimeouts {
+	return OperationTimeouts{Read: p.Read, Write: p.Write, Delete: p.Delete}
+}
+
+// clone returns a copy of the policy that does not share its Resources map.
+func (p *TimeoutPolicy) clone() *TimeoutPolicy {
+	clone := *p
+	clone.Resources = maps.Clone(p.Resources)
+	return &clone
+}
+
+type callTimeoutKey struct{}
+
+// WithCallTimeout returns a copy of ctx that gives the call made with it the
+// timeout d, overriding Config.Timeout and Config.Timeouts. Unlike
+// context.WithTimeout, the clock starts when the call starts, and a timeout
+// that runs out is reported like any other client timeout.
+func WithCallTimeout(ctx context.Context, d time.Duration) context.Context {
+	return context.WithValue(ctx, callTimeoutKey{}, d)
+}
+
+// callTimeout returns the timeout of the call made with req.
+func (c *Client) callTimeout(req *http.Request) time.Duration {
+	if d, ok := req.Context().Value(callTimeoutKey{}).(time.Duration); ok && d > 0 {
+		return d
+	}
+	if c.timeouts == nil {
+		return c.timeout
+	}
+
+	if call := transport.FromContext(req.Context()); call != nil {
+		if d := c.timeouts.Resources[call.Resource()].forClass(req.Method); d > 0 {
+			return d
+		}
+	}
+	if d := c.timeouts.defaults().forClass(req.Method); d > 0 {
+		return d
+	}
+	return c.timeout
+}
@@ -0,0 +1,117 @@
+package tama_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	tama "github.com/upmaru/tama-go"
+	"github.com/upmaru/tama-go/memory"
+	"github.com/upmaru/tama-go/neural"
+)
+
+func TestTimeoutPolicy(t *testing.T) {
+	server := createMockServer(t, spaceHandler)
+	defer server.Close()
+
+	client := tama.NewClient(tama.Config{
+		BaseURL: server.URL,
+		APIKey:  "test-key",
+		Timeout: time.Minute,
+		Timeouts: &tama.TimeoutPolicy{
+			Read:   2 * time.Second,
+			Delete: 10 * time.Minute,
+			Resources: map[string]tama.OperationTimeouts{
+				"prompt": {Read: 20 * time.Second},
+				"space":  {Delete: time.Hour},
+			},
+		},
+	})
+
+	// Record the time each call has left when it is sent.
+	var mu sync.Mutex
+	remaining := map[string]time.Duration{}
+	client.Use(func(next tama.Handler) tama.Handler {
+		return func(req *tama.Request) (*http.Response, error) {
+			deadline, ok := req.Context().Deadline()
+			mu.Lock()
+			if ok {
+				remaining[req.Operation] = time.Until(deadline)
+			}
+			mu.Unlock()
+			return next(req)
+		}
+	})
+
+	ctx := context.Background()
+	_, _ = client.Neural.GetSpace("space-123")
+	_, _ = client.Memory.GetPrompt("prompt-123")
+	_, _ = client.Neural.UpdateSpace("space-123", neural.UpdateSpaceRequest{})
+	_ = client.Neural.DeleteSpace("space-123")
+	_ = client.Memory.DeletePrompt("prompt-123")
+	_, _ = client.Memory.UpdatePromptWithContext(
+		tama.WithCallTimeout(ctx, 3*time.Hour), "prompt-123", memory.UpdatePromptRequest{})
+
+	shortCtx, cancel := context.WithTimeout(tama.WithCallTimeout(ctx, 3*time.Hour), 5*time.Second)
+	defer cancel()
+	_, _ = client.Memory.ReplacePromptWithContext(shortCtx, "prompt-123", memory.UpdatePromptRequest{})
+
+	want := map[string]time.Duration{
+		"neural.GetSpace":      2 * time.Second,  // class default
+		"memory.GetPrompt":     20 * time.Second, // resource override
+		"neural.UpdateSpace":   time.Minute,      // Config.Timeout
+		"neural.DeleteSpace":   time.Hour,        // resource override
+		"memory.DeletePrompt":  10 * time.Minute, // class default
+		"memory.UpdatePrompt":  3 * time.Hour,    // per call
+		"memory.ReplacePrompt": 5 * time.Second,  // caller deadline
+	}
+	for operation, timeout := range want {
+		got, ok := remaining[operation]
+		if !ok || got > timeout || got < timeout-time.Second {
+			t.Errorf("Expected %s to have %s, got %s", operation, timeout, got)
+		}
+	}
+}
+
+func TestTimeoutPolicyExpires(t *testing.T) {
+	release := make(chan struct{})
+	server := createMockServer(t, func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	})
+	defer server.Close()
+	defer close(release)
+
+	client := tama.NewClient(tama.Config{
+		BaseURL:  server.URL,
+		APIKey:   "test-key",
+		Timeouts: &tama.TimeoutPolicy{Read: 20 * time.Millisecond},
+	})
+
+	start := time.Now()
+	_, err := client.Neural.GetSpace("space-123")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected context.DeadlineExceeded, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Expected the read timeout to apply, took %s", elapsed)
+	}
+}
+
+func TestTimeoutPolicyValidation(t *testing.T) {
+	_, err := tama.NewClientWithOptions(
+		tama.WithBaseURL("https://api.tama.io"),
+		tama.WithTimeouts(tama.TimeoutPolicy{
+			Resources: map[string]tama.OperationTimeouts{"space": {Delete: -time.Second}},
+		}),
+	)
+	var configErr *tama.ConfigError
+	if !errors.As(err, &configErr) || configErr.Source != "Timeouts" {
+		t.Errorf("Expected a Timeouts ConfigError, got %v", err)
+	}
+}
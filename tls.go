@@ -0,0 +1,224 @@
+package tama
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TLSConfig customises how the client establishes TLS connections, e.g. to
+// reach the API through a gateway with a private CA that requires mutual TLS.
+type TLSConfig struct {
+	// RootCAFiles lists PEM files with CA certificates that are trusted in
+	// addition to the system roots.
+	RootCAFiles []string
+	// CertFile and KeyFile hold a PEM client certificate and its private key
+	// for mutual TLS. They are read again whenever either file's size or
+	// modification time changes, so certificates can be rotated by replacing
+	// the files; new connections present the new certificate.
+	CertFile string
+	KeyFile  string
+	// MinVersion is the minimum TLS version, e.g. tls.VersionTLS13. Zero
+	// means tls.VersionTLS12.
+	MinVersion uint16
+	// PinnedKeys, if set, restricts the server to verified certificate chains
+	// that contain one of these public keys, in addition to the usual
+	// verification.
+	// Each pin is the base64-encoded SHA-256 digest of a certificate's
+	// DER-encoded SubjectPublicKeyInfo, optionally prefixed with "sha256/":
+	//
+	//	openssl x509 -in cert.pem -pubkey -noout | openssl pkey -pubin -outform der |
+	//		openssl dgst -sha256 -binary | base64
+	PinnedKeys []string
+}
+
+// PinMismatchError is returned when no verified chain of the server's
+// certificate contains one of the public keys in TLSConfig.PinnedKeys.
+type PinMismatchError struct {
+	// Host is the server name that was verified.
+	Host string
+	// Keys holds the pins of the public keys in the verified chains.
+	Keys []string
+}
+
+func (e *PinMismatchError) Error() string {
+	return fmt.Sprintf("public key pin mismatch for %s: server presented %s", e.Host, strings.Join(e.Keys, ", "))
+}
+
+// SPKIPin returns the pin of a certificate's public key in the format used by
+// TLSConfig.PinnedKeys.
+func SPKIPin(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// build returns the *tls.Config described by c. Root CAs and pins are read and
+// checked here; the client certificate is loaded to check it but read again
+// on every handshake.
+func (c *TLSConfig) build() (*tls.Config, error) {
+	config := &tls.Config{MinVersion: c.MinVersion}
+	if config.MinVersion == 0 {
+		config.MinVersion = tls.VersionTLS12
+	}
+	if config.MinVersion < tls.VersionTLS10 || config.MinVersion > tls.VersionTLS13 {
+		return nil, fmt.Errorf("unsupported minimum TLS version %#04x", c.MinVersion)
+	}
+
+	if len(c.RootCAFiles) > 0 {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		for _, file := range c.RootCAFiles {
+			data, err := os.ReadFile(file)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read root CA file: %w", err)
+			}
+			if !pool.AppendCertsFromPEM(data) {
+				return nil, fmt.Errorf("no certificates found in root CA file %s", file)
+			}
+		}
+		config.RootCAs = pool
+	}
+
+	if c.CertFile != "" || c.KeyFile != "" {
+		if c.CertFile == "" || c.KeyFile == "" {
+			return nil, errors.New("client certificate requires both CertFile and KeyFile")
+		}
+		cert := &reloadingCertificate{certFile: c.CertFile, keyFile: c.KeyFile}
+		if _, err := cert.certificate(); err != nil {
+			return nil, err
+		}
+		config.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			return cert.certificate()
+		}
+	}
+
+	if len(c.PinnedKeys) > 0 {
+		pins := make(map[string]bool, len(c.PinnedKeys))
+		for _, pin := range c.PinnedKeys {
+			pin = strings.TrimPrefix(pin, "sha256/")
+			if sum, err := base64.StdEncoding.DecodeString(pin); err != nil || len(sum) != sha256.Size {
+				return nil, fmt.Errorf("invalid public key pin %q", pin)
+			}
+			pins[pin] = true
+		}
+		config.VerifyConnection = func(state tls.ConnectionState) error {
+			return verifyPins(state, pins)
+		}
+	}
+
+	return config, nil
+}
+
+// verifyPins checks that a verified chain contains one of the pinned keys.
+// Certificates the server sent that are not part of a verified chain are
+// ignored, so a server cannot pass by presenting a pinned certificate
+// alongside an unrelated one.
+func verifyPins(state tls.ConnectionState, pins map[string]bool) error {
+	var presented []string
+	for _, chain := range state.VerifiedChains {
+		for _, cert := range chain {
+			pin := SPKIPin(cert)
+			if pins[pin] {
+				return nil
+			}
+			if !slices.Contains(presented, pin) {
+				presented = append(presented, pin)
+			}
+		}
+	}
+	return &PinMismatchError{Host: state.ServerName, Keys: presented}
+}
+
+// reloadingCertificate loads a client certificate from files and reloads it
+// when the files change.
+type reloadingCertificate struct {
+	certFile string
+	keyFile  string
+	mu       sync.Mutex
+	cert     *tls.Certificate
+	stamp    [2]fileStamp
+}
+
+// fileStamp identifies the version of a file.
+type fileStamp struct {
+	size    int64
+	modTime time.Time
+}
+
+// certificate returns the current client certificate.
+func (r *reloadingCertificate) certificate() (*tls.Certificate, error) {
+	var stamp [2]fileStamp
+	for i, file := range []string{r.certFile, r.keyFile} {
+		info, err := os.Stat(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read client certificate: %w", err)
+		}
+		stamp[i] = fileStamp{size: info.Size(), modTime: info.ModTime()}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.cert != nil && stamp == r.stamp {
+		return r.cert, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load client certificate: %w", err)
+	}
+	r.cert, r.stamp = &cert, stamp
+	return r.cert, nil
+}
+
+// tlsHTTPClient returns a copy of httpClient whose transport uses the given
+// TLS settings. The transport must be an *http.Transport, or nil for a clone
+// of http.DefaultTransport.
+func tlsHTTPClient(httpClient *http.Client, config *TLSConfig) (*http.Client, error) {
+	tlsConfig, err := config.build()
+	if err != nil {
+		return nil, err
+	}
+
+	var base *http.Transport
+	switch t := httpClient.Transport.(type) {
+	case nil:
+		base, _ = http.DefaultTransport.(*http.Transport)
+	case *http.Transport:
+		base = t
+	}
+	if base == nil {
+		return nil, fmt.Errorf("TLS settings require an *http.Transport, got %T", httpClient.Transport)
+	}
+
+	transport := base.Clone()
+	transport.TLSClientConfig = tlsConfig
+	client := *httpClient
+	client.Transport = transport
+	return &client, nil
+}
+
+// failingTransport is an http.RoundTripper that fails every request, used when
+// NewClient is given settings it cannot apply.
+type failingTransport struct {
+	err error
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t failingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+	return nil, t.err
+}
@@ -0,0 +1,304 @@
+package tama_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	tama "github.com/upmaru/tama-go"
+)
+
+// writePEM writes a single PEM block to a new file in dir.
+func writePEM(t *testing.T, dir, name, blockType string, der []byte) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// writeClientCert generates a self-signed client certificate with the given
+// common name and writes it and its key to cert.pem and key.pem in dir.
+func writeClientCert(t *testing.T, dir, commonName string) (string, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return writePEM(t, dir, "cert.pem", "CERTIFICATE", der), writePEM(t, dir, "key.pem", "EC PRIVATE KEY", keyDER)
+}
+
+// issueCert creates a certificate for 127.0.0.1 signed by parent, or a
+// self-signed one if parent is nil.
+func issueCert(
+	t *testing.T, commonName string, isCA bool, parent *x509.Certificate, parentKey *ecdsa.PrivateKey,
+) (*x509.Certificate, *ecdsa.PrivateKey) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: commonName},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IPAddresses:           []net.IP{net.IPv4(127, 0, 0, 1)},
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  isCA,
+	}
+	if isCA {
+		template.KeyUsage = x509.KeyUsageCertSign
+	}
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert, key
+}
+
+// spaceHandler answers every request with a space.
+func spaceHandler(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write([]byte(`{"data":{"id":"space-123"}}`))
+}
+
+func TestTLSRootCAFiles(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(spaceHandler))
+	defer server.Close()
+
+	client := tama.NewClient(tama.Config{BaseURL: server.URL, APIKey: "test-key"})
+	if _, err := client.Neural.GetSpace("space-123"); err == nil {
+		t.Fatal("Expected an untrusted server certificate to be rejected")
+	}
+
+	caFile := writePEM(t, t.TempDir(), "ca.pem", "CERTIFICATE", server.Certificate().Raw)
+	client, err := tama.NewClientWithOptions(
+		tama.WithBaseURL(server.URL),
+		tama.WithAPIKey("test-key"),
+		tama.WithTLS(tama.TLSConfig{RootCAFiles: []string{caFile}}),
+	)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := client.Neural.GetSpace("space-123"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+}
+
+func TestTLSClientCertificateReload(t *testing.T) {
+	var commonNames []string
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		commonNames = append(commonNames, r.TLS.PeerCertificates[0].Subject.CommonName)
+		// Close the connection so the next request performs a new handshake.
+		w.Header().Set("Connection", "close")
+		spaceHandler(w, r)
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+	server.StartTLS()
+	defer server.Close()
+
+	dir := t.TempDir()
+	caFile := writePEM(t, dir, "ca.pem", "CERTIFICATE", server.Certificate().Raw)
+	certFile, keyFile := writeClientCert(t, dir, "first")
+
+	client, err := tama.NewClientWithOptions(
+		tama.WithBaseURL(server.URL),
+		tama.WithAPIKey("test-key"),
+		tama.WithTLS(tama.TLSConfig{RootCAFiles: []string{caFile}, CertFile: certFile, KeyFile: keyFile}),
+	)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := client.Neural.GetSpace("space-123"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	writeClientCert(t, dir, "second")
+	later := time.Now().Add(time.Minute)
+	for _, file := range []string{certFile, keyFile} {
+		if err := os.Chtimes(file, later, later); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := client.Neural.GetSpace("space-123"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(commonNames) != 2 || commonNames[0] != "first" || commonNames[1] != "second" {
+		t.Errorf("Expected certificates first and second, got %v", commonNames)
+	}
+}
+
+func TestTLSMinVersion(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(spaceHandler))
+	server.TLS = &tls.Config{MaxVersion: tls.VersionTLS12}
+	server.StartTLS()
+	defer server.Close()
+
+	caFile := writePEM(t, t.TempDir(), "ca.pem", "CERTIFICATE", server.Certificate().Raw)
+	for _, tt := range []struct {
+		version uint16
+		ok      bool
+	}{
+		{version: tls.VersionTLS12, ok: true},
+		{version: tls.VersionTLS13, ok: false},
+	} {
+		client, err := tama.NewClientWithOptions(
+			tama.WithBaseURL(server.URL),
+			tama.WithAPIKey("test-key"),
+			tama.WithTLS(tama.TLSConfig{RootCAFiles: []string{caFile}, MinVersion: tt.version}),
+		)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		_, err = client.Neural.GetSpace("space-123")
+		if (err == nil) != tt.ok {
+			t.Errorf("MinVersion %s: expected success %v, got error %v", tls.VersionName(tt.version), tt.ok, err)
+		}
+	}
+}
+
+func TestTLSPinning(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(spaceHandler))
+	defer server.Close()
+
+	caFile := writePEM(t, t.TempDir(), "ca.pem", "CERTIFICATE", server.Certificate().Raw)
+	newClient := func(pin string) *tama.Client {
+		client, err := tama.NewClientWithOptions(
+			tama.WithBaseURL(server.URL),
+			tama.WithAPIKey("test-key"),
+			tama.WithTLS(tama.TLSConfig{RootCAFiles: []string{caFile}, PinnedKeys: []string{pin}}),
+		)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		return client
+	}
+
+	if _, err := newClient("sha256/" + tama.SPKIPin(server.Certificate())).Neural.GetSpace("space-123"); err != nil {
+		t.Fatalf("Expected matching pin to succeed, got %v", err)
+	}
+
+	_, err := newClient("AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA=").Neural.GetSpace("space-123")
+	var mismatch *tama.PinMismatchError
+	if !errors.As(err, &mismatch) {
+		t.Fatalf("Expected PinMismatchError, got %v", err)
+	}
+	if len(mismatch.Keys) != 1 || mismatch.Keys[0] != tama.SPKIPin(server.Certificate()) {
+		t.Errorf("Expected the server's pin to be reported, got %v", mismatch.Keys)
+	}
+
+	// The server has a certificate from a trusted CA and also presents the
+	// pinned certificate, which is not part of its verified chain.
+	ca, caKey := issueCert(t, "trusted-ca", true, nil, nil)
+	leaf, leafKey := issueCert(t, "127.0.0.1", false, ca, caKey)
+	pinned, _ := issueCert(t, "pinned", false, nil, nil)
+
+	decoy := httptest.NewUnstartedServer(http.HandlerFunc(spaceHandler))
+	decoy.TLS = &tls.Config{Certificates: []tls.Certificate{{
+		Certificate: [][]byte{leaf.Raw, pinned.Raw},
+		PrivateKey:  leafKey,
+	}}}
+	decoy.StartTLS()
+	defer decoy.Close()
+
+	trustedCAFile := writePEM(t, t.TempDir(), "ca.pem", "CERTIFICATE", ca.Raw)
+	get := func(pin string) error {
+		client, err := tama.NewClientWithOptions(
+			tama.WithBaseURL(decoy.URL),
+			tama.WithAPIKey("test-key"),
+			tama.WithTLS(tama.TLSConfig{RootCAFiles: []string{trustedCAFile}, PinnedKeys: []string{pin}}),
+		)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		_, err = client.Neural.GetSpace("space-123")
+		return err
+	}
+
+	if err := get(tama.SPKIPin(pinned)); !errors.As(err, &mismatch) {
+		t.Fatalf("Expected a pinned certificate outside the verified chain to be rejected, got %v", err)
+	}
+	if len(mismatch.Keys) != 2 || mismatch.Keys[0] != tama.SPKIPin(leaf) || mismatch.Keys[1] != tama.SPKIPin(ca) {
+		t.Errorf("Expected the keys of the verified chain, got %v", mismatch.Keys)
+	}
+
+	// Pinning the CA in the verified chain is accepted.
+	if err := get(tama.SPKIPin(ca)); err != nil {
+		t.Errorf("Expected a pinned CA key to succeed, got %v", err)
+	}
+}
+
+func TestTLSInvalidSettings(t *testing.T) {
+	dir := t.TempDir()
+	notPEM := filepath.Join(dir, "ca.pem")
+	if err := os.WriteFile(notPEM, []byte("not a certificate"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		config tama.TLSConfig
+	}{
+		{name: "missing root CA file", config: tama.TLSConfig{RootCAFiles: []string{filepath.Join(dir, "missing.pem")}}},
+		{name: "root CA file without certificates", config: tama.TLSConfig{RootCAFiles: []string{notPEM}}},
+		{name: "certificate without key", config: tama.TLSConfig{CertFile: notPEM}},
+		{name: "unloadable key pair", config: tama.TLSConfig{CertFile: notPEM, KeyFile: notPEM}},
+		{name: "malformed pin", config: tama.TLSConfig{PinnedKeys: []string{"not-a-pin"}}},
+		{name: "unsupported version", config: tama.TLSConfig{MinVersion: 0x0200}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tama.NewClientWithOptions(tama.WithBaseURL("https://api.tama.io"), tama.WithTLS(tt.config))
+			var configErr *tama.ConfigError
+			if !errors.As(err, &configErr) || configErr.Source != "TLS" {
+				t.Fatalf("Expected TLS ConfigError, got %v", err)
+			}
+
+			// NewClient cannot report the error, so every request fails with it.
+			client := tama.NewClient(tama.Config{BaseURL: "https://api.tama.io", TLS: &tt.config})
+			if _, err := client.Neural.GetSpace("space-123"); err == nil {
+				t.Error("Expected requests to fail")
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package tama
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/upmaru/tama-go/internal/transport"
+)
+
+// Span attribute keys set on every traced call. The HTTP keys follow the
+// OpenTelemetry semantic conventions.
+const (
+	// AttributeHTTPMethod is the HTTP method of the request.
+	AttributeHTTPMethod = "http.request.method"
+	// AttributeHTTPRoute is the path template of the endpoint, e.g. "/provision/sensory/sources/{id}".
+	AttributeHTTPRoute = "http.route"
+	// AttributeHTTPStatusCode is the status code of the final response.
+	AttributeHTTPStatusCode = "http.response.status_code"
+	// AttributeRetryCount is the number of times the request was resent.
+	AttributeRetryCount = "http.request.resend_count"
+	// AttributeResourceID is the ID of the resource the call reads or modifies.
+	AttributeResourceID = "tama.resource.id"
+	// AttributeParentID is the ID of the parent resource a create call adds to.
+	AttributeParentID = "tama.parent.id"
+)
+
+// Tracer starts a span for every API call made by a client. Spans are named
+// after the operation, e.g. "tama.neural.CreateSpace", and cover every attempt
+// of the call. The tamaotel package provides an OpenTelemetry implementation.
+type Tracer interface {
+	// Start begins a span as a child of any span carried by ctx and returns a
+	// context that carries the new span.
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+// Span records a single traced API call.
+type Span interface {
+	SetAttributes(attrs ...Attribute)
+	// RecordError marks the span as failed.
+	RecordError(err error)
+	End()
+}
+
+// HeaderInjector is implemented by tracers that propagate the trace context
+// to the API, for example as a W3C traceparent header.
+type HeaderInjector interface {
+	Inject(ctx context.Context, header http.Header)
+}
+
+// Attribute is a key-value pair attached to a span. Value is a string or an int.
+type Attribute struct {
+	Key   string
+	Value any
+}
+
+// startSpan starts the span of a call if the client has a tracer. The
+// returned span is nil otherwise.
+func (c *Client) startSpan(ctx context.Context) (context.Context, Span) {
+	if c.tracer == nil {
+		return ctx, nil
+	}
+
+	name := "tama.request"
+	if call := transport.FromContext(ctx); call != nil {
+		name = "tama." + call.Operation
+	}
+	return c.tracer.Start(ctx, name)
+}
+
+// injectTraceContext adds the trace context of req to its headers.
+func (c *Client) injectTraceContext(req *http.Request) {
+	if injector, ok := c.tracer.(HeaderInjector); ok {
+		injector.Inject(req.Context(), req.Header)
+	}
+}
+
+// endSpan records the outcome of a call on its span and ends it.
+func endSpan(span Span, req *http.Request, resp *http.Response, err error) {
+	defer span.End()
+
+	attrs := []Attribute{{Key: AttributeHTTPMethod, Value: req.Method}}
+	if call := transport.FromContext(req.Context()); call != nil {
+		attrs = append(attrs, Attribute{Key: AttributeHTTPRoute, Value: call.Route})
+		if call.ResourceID != "" {
+			attrs = append(attrs, Attribute{Key: AttributeResourceID, Value: call.ResourceID})
+		}
+		if call.ParentID != "" {
+			attrs = append(attrs, Attribute{Key: AttributeParentID, Value: call.ParentID})
+		}
+		if call.Attempts > 1 {
+			attrs = append(attrs, Attribute{Key: AttributeRetryCount, Value: call.Attempts - 1})
+		}
+	}
+	if resp != nil {
+		attrs = append(attrs, Attribute{Key: AttributeHTTPStatusCode, Value: resp.StatusCode})
+	}
+	span.SetAttributes(attrs...)
+
+	switch {
+	case err != nil:
+		span.RecordError(err)
+	case resp.StatusCode >= http.StatusBadRequest:
+		span.RecordError(fmt.Errorf("API error: %s", resp.Status))
+	}
+}
@@ -0,0 +1,163 @@
+package tama_test
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+
+	tama "github.com/upmaru/tama-go"
+	"github.com/upmaru/tama-go/sensory"
+)
+
+type recordedSpan struct {
+	name   string
+	attrs  map[string]any
+	errs   []error
+	ended  bool
+	tracer *recordingTracer
+}
+
+func (s *recordedSpan) SetAttributes(attrs ...tama.Attribute) {
+	s.tracer.mu.Lock()
+	defer s.tracer.mu.Unlock()
+	for _, attr := range attrs {
+		s.attrs[attr.Key] = attr.Value
+	}
+}
+
+func (s *recordedSpan) RecordError(err error) {
+	s.tracer.mu.Lock()
+	defer s.tracer.mu.Unlock()
+	s.errs = append(s.errs, err)
+}
+
+func (s *recordedSpan) End() {
+	s.tracer.mu.Lock()
+	defer s.tracer.mu.Unlock()
+	s.ended = true
+}
+
+type recordingTracer struct {
+	mu    sync.Mutex
+	spans []*recordedSpan
+}
+
+func (t *recordingTracer) Start(ctx context.Context, name string) (context.Context, tama.Span) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	span := &recordedSpan{name: name, attrs: make(map[string]any), tracer: t}
+	t.spans = append(t.spans, span)
+	return ctx, span
+}
+
+func (t *recordingTracer) Inject(_ context.Context, header http.Header) {
+	header.Set("Traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+}
+
+func TestTracingRecordsSpanPerCall(t *testing.T) {
+	var traceparent string
+	server := createMockServer(t, func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("Traceparent")
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"data":{"id":"source-123","name":"Test"}}`))
+	})
+	defer server.Close()
+
+	tracer := &recordingTracer{}
+	client := tama.NewClient(tama.Config{BaseURL: server.URL, APIKey: "test-key", Tracer: tracer})
+
+	if _, err := client.Sensory.GetSource("source-123"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if traceparent == "" {
+		t.Error("Expected traceparent header to be injected")
+	}
+
+	if len(tracer.spans) != 1 {
+		t.Fatalf("Expected 1 span, got %d", len(tracer.spans))
+	}
+
+	span := tracer.spans[0]
+	if span.name != "tama.sensory.GetSource" {
+		t.Errorf("Expected span name tama.sensory.GetSource, got %s", span.name)
+	}
+	if !span.ended {
+		t.Error("Expected span to be ended")
+	}
+
+	expected := map[string]any{
+		tama.AttributeHTTPMethod:     http.MethodGet,
+		tama.AttributeHTTPRoute:      "/provision/sensory/sources/{id}",
+		tama.AttributeHTTPStatusCode: http.StatusOK,
+		tama.AttributeResourceID:     "source-123",
+	}
+	for key, value := range expected {
+		if span.attrs[key] != value {
+			t.Errorf("Expected attribute %s to be %v, got %v", key, value, span.attrs[key])
+		}
+	}
+	if _, ok := span.attrs[tama.AttributeRetryCount]; ok {
+		t.Error("Expected no retry count on a call that was not retried")
+	}
+	if len(span.errs) != 0 {
+		t.Errorf("Expected no errors on span, got %v", span.errs)
+	}
+}
+
+func TestTracingRecordsRetriesAndErrors(t *testing.T) {
+	server := createMockServer(t, func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	defer server.Close()
+
+	tracer := &recordingTracer{}
+	client := tama.NewClient(tama.Config{
+		BaseURL: server.URL,
+		APIKey:  "test-key",
+		Retry:   fastRetryPolicy(),
+		Tracer:  tracer,
+	})
+
+	createReq := sensory.CreateSourceRequest{
+		Source: sensory.SourceRequestData{
+			Name:       "Test",
+			Type:       "model",
+			Endpoint:   "https://api.example.com",
+			Credential: sensory.SourceCredential{APIKey: "key"},
+		},
+	}
+	if _, err := client.Sensory.CreateSource("space-123", createReq); err == nil {
+		t.Fatal("Expected error from unavailable server")
+	}
+	if err := client.Sensory.DeleteSource("source-123"); err == nil {
+		t.Fatal("Expected error from unavailable server")
+	}
+
+	if len(tracer.spans) != 2 {
+		t.Fatalf("Expected 2 spans, got %d", len(tracer.spans))
+	}
+
+	create := tracer.spans[0]
+	if create.attrs[tama.AttributeParentID] != "space-123" {
+		t.Errorf("Expected parent ID space-123, got %v", create.attrs[tama.AttributeParentID])
+	}
+	if _, ok := create.attrs[tama.AttributeResourceID]; ok {
+		t.Error("Expected no resource ID on a create span")
+	}
+	if create.attrs[tama.AttributeHTTPRoute] != "/provision/sensory/spaces/{space_id}/sources" {
+		t.Errorf("Unexpected route %v", create.attrs[tama.AttributeHTTPRoute])
+	}
+
+	remove := tracer.spans[1]
+	if remove.attrs[tama.AttributeRetryCount] != 2 {
+		t.Errorf("Expected retry count 2, got %v", remove.attrs[tama.AttributeRetryCount])
+	}
+	if remove.attrs[tama.AttributeHTTPStatusCode] != http.StatusServiceUnavailable {
+		t.Errorf("Expected status 503, got %v", remove.attrs[tama.AttributeHTTPStatusCode])
+	}
+	if len(remove.errs) != 1 {
+		t.Errorf("Expected the failed call to record an error, got %v", remove.errs)
+	}
+}
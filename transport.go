@@ -0,0 +1,182 @@
+package tama
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/upmaru/tama-go/internal/transport"
+)
+
+// clientTimeoutError is the cancellation cause of calls that exceed their
+// timeout. It matches context.DeadlineExceeded, so callers can detect a timeout
+// the same way whether it came from their own context or from the client.
+type clientTimeoutError struct{}
+
+func (clientTimeoutError) Error() string {
+	return "client timeout exceeded: " + context.DeadlineExceeded.Error()
+}
+
+func (clientTimeoutError) Unwrap() error {
+	return context.DeadlineExceeded
+}
+
+// callerGaveUp reports whether ctx was cancelled by the caller, as opposed to
+// running into the client's own timeout.
+func callerGaveUp(ctx context.Context) bool {
+	return ctx.Err() != nil && !errors.Is(context.Cause(ctx), clientTimeoutError{})
+}
+
+// sendFunc adapts a function to both http.RoundTripper and the Doer interface
+// of the service packages.
+type sendFunc func(req *http.Request) (*http.Response, error)
+
+// RoundTrip implements http.RoundTripper.
+func (f sendFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// Do implements the Doer interface of the service packages.
+func (f sendFunc) Do(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// do is the entry point for every request issued by the services. It applies
+// the client timeout and default headers, traces and measures the call and
+// runs the request through the client's transport stack. Mutations that
+// cannot reach the API are stored in the outbox, if there is one, and all
+// mutations are recorded in the audit log.
+func (c *Client) do(req *http.Request) (*http.Response, error) {
+	ctx, end, err := c.lifecycle.start(req.Context())
+	if err != nil {
+		return nil, err
+	}
+	defer end()
+	req = req.WithContext(ctx)
+
+	if err := c.checkCapabilities(req); err != nil {
+		return nil, err
+	}
+	prior, priorErr := c.priorState(req.Context(), req)
+
+	start := time.Now()
+	finish := c.observe(req.Context())
+	ctx, span := c.startSpan(req.Context())
+	if timeout := c.callTimeout(req); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeoutCause(ctx, timeout, clientTimeoutError{})
+		defer cancel()
+	}
+
+	req = req.Clone(ctx)
+
+	c.mu.RLock()
+	for header, values := range c.headers {
+		req.Header[header] = slices.Clone(values)
+	}
+	c.mu.RUnlock()
+	setIfMatch(req)
+	setIdempotencyKey(req)
+
+	if span != nil {
+		c.injectTraceContext(req)
+	}
+
+	var resp *http.Response
+	if c.behindOutbox(req) {
+		err = ErrOutboxPending
+	} else {
+		resp, err = c.roundTrip(req)
+	}
+	if err != nil && errors.Is(context.Cause(ctx), ErrClientClosed) && !errors.Is(err, ErrClientClosed) {
+		err = fmt.Errorf("%w: %w", ErrClientClosed, err)
+	}
+
+	if span != nil {
+		endSpan(span, req, resp, err)
+	}
+	duration := time.Since(start)
+	finish(ctx, resp, err, duration)
+	c.logCall(req, resp, err, duration)
+	recordResponseMeta(ctx, resp, duration)
+
+	if err != nil {
+		err = c.enqueue(ctx, req, err)
+	}
+	c.audit(ctx, req, prior, priorErr, resp, err)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusPreconditionFailed {
+		return nil, preconditionFailed(req, resp)
+	}
+	return resp, nil
+}
+
+// roundTrip authorizes req and sends it through the transport stack, retrying
+// once with a fresh credential if the API rejects the current one.
+func (c *Client) roundTrip(req *http.Request) (*http.Response, error) {
+	credential, err := c.authorize(req)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.transport.RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+
+	// The credential was rejected; retry once if the provider has a fresh one.
+	retry := c.reauthorize(req, credential)
+	if retry == nil {
+		return resp, nil
+	}
+	drain(resp)
+
+	return c.transport.RoundTrip(retry)
+}
+
+// send is the innermost layer of the transport stack. It sends req with the
+// underlying HTTP client and buffers the response body, so that the timeout
+// context can be released as soon as do returns.
+func (c *Client) send(req *http.Request) (*http.Response, error) {
+	if call := transport.FromContext(req.Context()); call != nil {
+		call.Attempts++
+	}
+	c.logRequest(req)
+	capture := c.captureRequest(req)
+
+	if c.compression != nil {
+		var err error
+		if req, err = c.compression.compressRequest(req); err != nil {
+			return nil, err
+		}
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		c.captureResponse(capture, nil, nil, err)
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+	if c.compression != nil && c.compression.config.Responses {
+		if body, err = decodeResponse(resp, body); err != nil {
+			return nil, err
+		}
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	c.logResponse(req, resp, body)
+	c.captureResponse(capture, resp, body, nil)
+
+	return resp, nil
+}